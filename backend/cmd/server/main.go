@@ -3,8 +3,11 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/handlers"
+	"fintrack-backend/internal/jobs"
 	"fintrack-backend/internal/routes"
 
 	"github.com/gin-contrib/cors"
@@ -20,6 +23,16 @@ func main() {
 
 	// Connect to Database
 	db.ConnectDB()
+	db.EnsureIndexes()
+
+	// Start background jobs
+	jobs.Every("goal-funding-schedules", time.Hour, handlers.RunScheduledFundingRules)
+	jobs.Daily("net-worth-snapshots", 23, 30, handlers.RecordNetWorthSnapshots)
+	jobs.Daily("subscription-detection", 3, 0, handlers.RunSubscriptionDetection)
+	jobs.Daily("anomaly-detection", 2, 0, handlers.RunAnomalyDetection)
+	jobs.Weekly("weekly-digests", time.Monday, 12, 0, handlers.SendWeeklyDigests)
+	jobs.Monthly("monthly-statements", 1, 6, 0, handlers.GenerateMonthlyStatements)
+	jobs.Start()
 
 	// Initialize Gin
 	r := gin.Default()
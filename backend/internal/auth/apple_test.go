@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testAppleClientID = "com.example.fintrack.web"
+
+func newTestAppleKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func appleClaimsFor(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            AppleIssuer,
+		"aud":            testAppleClientID,
+		"sub":            "001234.abcdef.0001",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(10 * time.Minute).Unix(),
+		"email":          "Jane@Example.com",
+		"email_verified": true,
+		"nonce":          nonce,
+	}
+}
+
+func signAppleToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestAppleVerifierVerify(t *testing.T) {
+	key := newTestAppleKey(t)
+	otherKey := newTestAppleKey(t)
+	verifier := &AppleVerifier{ClientID: testAppleClientID, Keys: StaticKeySource{"k1": &key.PublicKey}}
+
+	with := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := appleClaimsFor("nonce-1")
+		for k, v := range changes {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+		return claims
+	}
+	rs256 := func(claims jwt.MapClaims) string {
+		return signAppleToken(t, jwt.SigningMethodRS256, key, "k1", claims)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		nonce   string
+		want    *AppleIdentity
+		wantErr bool
+	}{
+		{
+			name:  "valid token",
+			token: rs256(with(nil)),
+			nonce: "nonce-1",
+			want:  &AppleIdentity{Subject: "001234.abcdef.0001", Email: "jane@example.com", EmailVerified: true},
+		},
+		{
+			name:  "email_verified as a string",
+			token: rs256(with(jwt.MapClaims{"email_verified": "true", "is_private_email": "false"})),
+			nonce: "nonce-1",
+			want:  &AppleIdentity{Subject: "001234.abcdef.0001", Email: "jane@example.com", EmailVerified: true},
+		},
+		{
+			name:  "unverified email as a string",
+			token: rs256(with(jwt.MapClaims{"email_verified": "false"})),
+			nonce: "nonce-1",
+			want:  &AppleIdentity{Subject: "001234.abcdef.0001", Email: "jane@example.com"},
+		},
+		{
+			name:  "private relay email",
+			token: rs256(with(jwt.MapClaims{"email": "x7k2@privaterelay.appleid.com", "is_private_email": "true"})),
+			nonce: "nonce-1",
+			want:  &AppleIdentity{Subject: "001234.abcdef.0001", Email: "x7k2@privaterelay.appleid.com", EmailVerified: true, PrivateRelay: true},
+		},
+		{name: "wrong audience", token: rs256(with(jwt.MapClaims{"aud": "com.example.other"})), nonce: "nonce-1", wantErr: true},
+		{name: "wrong issuer", token: rs256(with(jwt.MapClaims{"iss": "https://evil.example.com"})), nonce: "nonce-1", wantErr: true},
+		{name: "expired", token: rs256(with(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})), nonce: "nonce-1", wantErr: true},
+		{name: "missing expiry", token: rs256(with(jwt.MapClaims{"exp": nil})), nonce: "nonce-1", wantErr: true},
+		{name: "nonce mismatch", token: rs256(with(nil)), nonce: "nonce-2", wantErr: true},
+		{name: "empty nonce", token: rs256(with(jwt.MapClaims{"nonce": ""})), nonce: "", wantErr: true},
+		{name: "missing subject", token: rs256(with(jwt.MapClaims{"sub": nil})), nonce: "nonce-1", wantErr: true},
+		{name: "unknown kid", token: signAppleToken(t, jwt.SigningMethodRS256, key, "k2", with(nil)), nonce: "nonce-1", wantErr: true},
+		{name: "signed by another key", token: signAppleToken(t, jwt.SigningMethodRS256, otherKey, "k1", with(nil)), nonce: "nonce-1", wantErr: true},
+		{name: "HS256", token: signAppleToken(t, jwt.SigningMethodHS256, []byte("secret"), "k1", with(nil)), nonce: "nonce-1", wantErr: true},
+		{name: "alg none", token: signAppleToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "k1", with(nil)), nonce: "nonce-1", wantErr: true},
+		{name: "garbage", token: "not.a.token", nonce: "nonce-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(context.Background(), tt.token, tt.nonce)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Verify = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("Verify = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWKSKeySource(t *testing.T) {
+	key := newTestAppleKey(t)
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer srv.Close()
+
+	source := NewJWKSKeySource(srv.URL)
+	verifier := &AppleVerifier{ClientID: testAppleClientID, Keys: source}
+	token := signAppleToken(t, jwt.SigningMethodRS256, key, "k1", appleClaimsFor("n"))
+
+	for i := 0; i < 2; i++ {
+		if _, err := verifier.Verify(context.Background(), token, "n"); err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("keys fetched %d times, want 1 (cached)", fetches)
+	}
+
+	// An unknown key ID right after a fetch does not hit the endpoint again
+	if _, err := source.Key(context.Background(), "k2"); err == nil {
+		t.Error("Key(k2) succeeded, want error")
+	}
+	if fetches != 1 {
+		t.Errorf("keys fetched %d times after an unknown kid, want 1", fetches)
+	}
+}
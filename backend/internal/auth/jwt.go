@@ -10,8 +10,12 @@ import (
 
 var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
 
+// AccessTokenTTL is the lifetime of an access token; refresh tokens renew it
+const AccessTokenTTL = 15 * time.Minute
+
 type CustomClaims struct {
-	UserID string `json:"user_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -23,11 +27,13 @@ func GetSecret() []byte {
 	return jwtSecret
 }
 
-func GenerateToken(userID string) (string, error) {
+// GenerateToken issues an access token for userID within the given session
+func GenerateToken(userID, sessionID string) (string, error) {
 	claims := CustomClaims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour * 30)), // 30 days
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "fintrack-backend",
 		},
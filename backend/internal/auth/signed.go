@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// Sign appends an HMAC of value so it can round-trip through the client (e.g. in
+// a cookie) without being tampered with. purpose keeps values signed for one use
+// from being accepted for another.
+func Sign(purpose, value string) string {
+	return value + "." + base64.RawURLEncoding.EncodeToString(signature(purpose, value))
+}
+
+// Verify returns the value of a string produced by Sign with the same purpose
+func Verify(purpose, signed string) (string, bool) {
+	i := strings.LastIndex(signed, ".")
+	if i < 0 {
+		return "", false
+	}
+	value := signed[:i]
+	mac, err := base64.RawURLEncoding.DecodeString(signed[i+1:])
+	if err != nil || !hmac.Equal(mac, signature(purpose, value)) {
+		return "", false
+	}
+	return value, true
+}
+
+func signature(purpose, value string) []byte {
+	mac := hmac.New(sha256.New, GetSecret())
+	mac.Write([]byte(purpose + ":" + value))
+	return mac.Sum(nil)
+}
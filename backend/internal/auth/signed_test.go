@@ -0,0 +1,34 @@
+package auth
+
+import "testing"
+
+func TestSignVerify(t *testing.T) {
+	signed := Sign("oauth_state", "abc.def")
+	value, ok := Verify("oauth_state", signed)
+	if !ok || value != "abc.def" {
+		t.Fatalf("Verify = %q, %v; want abc.def, true", value, ok)
+	}
+
+	tests := map[string]struct {
+		purpose, signed string
+	}{
+		"other purpose":    {"unsubscribe", signed},
+		"changed value":    {"oauth_state", "abd.def" + signed[len("abc.def"):]},
+		"changed mac":      {"oauth_state", signed[:len(signed)-1] + flip(signed[len(signed)-1])},
+		"missing mac":      {"oauth_state", "abc"},
+		"invalid encoding": {"oauth_state", "abc.!!!"},
+		"empty":            {"oauth_state", ""},
+	}
+	for name, tt := range tests {
+		if _, ok := Verify(tt.purpose, tt.signed); ok {
+			t.Errorf("%s: Verify accepted %q", name, tt.signed)
+		}
+	}
+}
+
+func flip(c byte) string {
+	if c == 'A' {
+		return "B"
+	}
+	return "A"
+}
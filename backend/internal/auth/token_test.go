@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestNewOpaqueToken(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		token, hash, err := NewOpaqueToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(token) != 43 || strings.ContainsAny(token, "+/=") {
+			t.Errorf("token %q is not 32 bytes of unpadded URL-safe base64", token)
+		}
+		if hash != HashToken(token) || len(hash) != 64 {
+			t.Errorf("hash %q does not match HashToken(token)", hash)
+		}
+		if seen[token] {
+			t.Fatalf("duplicate token %q", token)
+		}
+		seen[token] = true
+	}
+}
+
+func TestGenerateTokenClaims(t *testing.T) {
+	signed, err := GenerateToken("64b000000000000000000001", "64b000000000000000000002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateToken(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != "64b000000000000000000001" || claims.SessionID != "64b000000000000000000002" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl > AccessTokenTTL || ttl < AccessTokenTTL-time.Minute {
+		t.Errorf("token expires in %v, want about %v", ttl, AccessTokenTTL)
+	}
+}
+
+func TestValidateTokenRejects(t *testing.T) {
+	valid, _ := GenerateToken("64b000000000000000000001", "64b000000000000000000002")
+
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, CustomClaims{
+		UserID: "64b000000000000000000001",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}).SignedString(GetSecret())
+
+	otherSecret, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, CustomClaims{
+		UserID: "64b000000000000000000001",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}).SignedString([]byte("another secret"))
+
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, CustomClaims{
+		UserID: "64b000000000000000000001",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	// Swap the payload for one naming another user, keeping the signature
+	parts := strings.Split(valid, ".")
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, CustomClaims{UserID: "64b000000000000000000003"}).SigningString()
+	tampered := forged + "." + parts[2]
+
+	tests := map[string]string{
+		"expired":      expired,
+		"other secret": otherSecret,
+		"alg none":     unsigned,
+		"tampered":     tampered,
+		"garbage":      "not-a-token",
+		"empty":        "",
+	}
+	for name, token := range tests {
+		if _, err := ValidateToken(token); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B uses the ASCII secret "12345678901234567890" with SHA-1;
+// the expected values are the last six digits of its eight-digit codes
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, tt.unix/totpPeriod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.code {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestTOTPCodeAcceptsLowercaseSecret(t *testing.T) {
+	upper, _ := TOTPCode(rfc6238Secret, 1)
+	lower, err := TOTPCode(strings.ToLower(rfc6238Secret), 1)
+	if err != nil || lower != upper {
+		t.Errorf("lowercase secret = %q, %v; want %q", lower, err, upper)
+	}
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name   string
+		offset int64 // Steps between the code and now
+		ok     bool
+	}{
+		{"current step", 0, true},
+		{"previous step", -1, true},
+		{"next step", 1, true},
+		{"two steps old", -2, false},
+		{"two steps ahead", 2, false},
+	}
+	for _, tt := range tests {
+		code, _ := TOTPCode(rfc6238Secret, current+tt.offset)
+		step, ok := ValidateTOTP(rfc6238Secret, code, now)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+		if ok && step != current+tt.offset {
+			t.Errorf("%s: step = %d, want %d", tt.name, step, current+tt.offset)
+		}
+	}
+}
+
+func TestValidateTOTPReturnsStepForReplayCheck(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := TOTPCode(rfc6238Secret, now.Unix()/totpPeriod)
+
+	// The same code validates to the same step throughout its window, which is
+	// what lets callers reject it once last_used_step has reached that step
+	first, ok := ValidateTOTP(rfc6238Secret, code, now)
+	if !ok {
+		t.Fatal("code rejected")
+	}
+	second, ok := ValidateTOTP(rfc6238Secret, code, now.Add(totpPeriod*time.Second))
+	if !ok || second != first {
+		t.Errorf("replayed code matched step %d (ok=%v), want %d", second, ok, first)
+	}
+
+	// A later code always has a later step
+	next, _ := TOTPCode(rfc6238Secret, first+1)
+	step, ok := ValidateTOTP(rfc6238Secret, next, now.Add(totpPeriod*time.Second))
+	if !ok || step <= first {
+		t.Errorf("next code step = %d (ok=%v), want > %d", step, ok, first)
+	}
+}
+
+func TestValidateTOTPInputFormats(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, code := range []string{"287082", " 287082 ", "287 082"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, now); !ok {
+			t.Errorf("ValidateTOTP(%q) rejected", code)
+		}
+	}
+	for _, code := range []string{"", "28708", "2870821", "abcdef", "287083"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, now); ok {
+			t.Errorf("ValidateTOTP(%q) accepted", code)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || strings.ToLower(code) != code {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+
+		for _, typed := range []string{code, strings.ToUpper(code), strings.ReplaceAll(code, "-", ""), " " + code + " "} {
+			if got := NormalizeRecoveryCode(typed); got != code {
+				t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", typed, got, code)
+			}
+		}
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("JBSWY3DPEHPK3PXP", "jane@example.com", "FinTrack")
+	for _, want := range []string{"otpauth://totp/FinTrack:jane@example.com?", "secret=JBSWY3DPEHPK3PXP", "issuer=FinTrack", "digits=6", "period=30"} {
+		if !strings.Contains(uri, want) {
+			t.Errorf("TOTPURI = %q, missing %q", uri, want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Package email renders templated emails and delivers them through the mailer,
+// honoring the recipient's list subscriptions
+package email
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/mailer"
+	"fintrack-backend/internal/models"
+	"fintrack-backend/internal/statement"
+)
+
+//go:embed templates
+var files embed.FS
+
+// ErrUnknownTemplate is returned by Render for a template that does not exist
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// View is the data every template is executed with
+type View struct {
+	Name           string // Recipient's name
+	AppURL         string
+	UnsubscribeURL string // Empty for transactional mail
+	Data           interface{}
+}
+
+// Content is a rendered email
+type Content struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type pair struct {
+	text *template.Template
+	html *htmltemplate.Template
+}
+
+var templates = map[string]pair{}
+
+var funcs = map[string]interface{}{
+	"money":   statement.Money,
+	"percent": func(v float64) string { return fmt.Sprintf("%.0f%%", v) },
+	"date":    func(t time.Time) string { return t.Format("Jan 2, 2006") },
+	"month":   func(t time.Time) string { return t.Format("January 2006") },
+}
+
+// Every template is a name.txt / name.html pair. The text file defines the
+// "subject" block and both are rendered inside their layout.
+func init() {
+	names, err := fs.Glob(files, "templates/*.txt")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range names {
+		name := strings.TrimSuffix(path.Base(file), ".txt")
+		if name == "layout" {
+			continue
+		}
+		templates[name] = pair{
+			text: template.Must(template.New("layout.txt").Funcs(funcs).ParseFS(files, "templates/layout.txt", "templates/"+name+".txt")),
+			html: htmltemplate.Must(htmltemplate.New("layout.html").Funcs(funcs).ParseFS(files, "templates/layout.html", "templates/"+name+".html")),
+		}
+	}
+}
+
+// HasTemplate reports whether a template with the given name exists
+func HasTemplate(name string) bool {
+	_, ok := templates[name]
+	return ok
+}
+
+// Render executes the named template
+func Render(name string, v View) (*Content, error) {
+	t, ok := templates[name]
+	if !ok {
+		return nil, ErrUnknownTemplate
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", v); err != nil {
+		return nil, err
+	}
+	if err := t.text.Execute(&text, v); err != nil {
+		return nil, err
+	}
+	if err := t.html.Execute(&html, v); err != nil {
+		return nil, err
+	}
+
+	return &Content{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// AppURL is the base URL of the web app, used for links in emails
+func AppURL() string {
+	if u := os.Getenv("APP_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "http://localhost:5173"
+}
+
+// APIURL is the public base URL of this server, used for unsubscribe links
+func APIURL() string {
+	if u := os.Getenv("API_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "http://localhost:8080"
+}
+
+// LoadPreferences returns the user's email preferences, or the defaults if none are saved
+func LoadPreferences(ctx context.Context, userID primitive.ObjectID) models.EmailPreferences {
+	var prefs models.EmailPreferences
+	err := db.Client.Database("fintrack").Collection("email_preferences").FindOne(ctx, bson.M{"user_id": userID}).Decode(&prefs)
+	if err != nil {
+		return models.DefaultEmailPreferences(userID)
+	}
+	return prefs
+}
+
+// Send renders the named template for user and mails it. When list is set the
+// message is skipped if the user unsubscribed from it, and carries unsubscribe
+// links; an empty list marks transactional mail that is always sent.
+func Send(ctx context.Context, user models.User, list, name string, data interface{}, attachments ...mailer.Attachment) error {
+	if user.Email == "" {
+		return nil
+	}
+
+	view := View{Name: user.Name, AppURL: AppURL(), Data: data}
+	headers := map[string]string{}
+	if list != "" {
+		if !LoadPreferences(ctx, user.ID).Subscribed(list) {
+			return nil
+		}
+		view.UnsubscribeURL = UnsubscribeURL(user.ID, list)
+		headers["List-Unsubscribe"] = "<" + view.UnsubscribeURL + ">"
+		headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+	}
+
+	content, err := Render(name, view)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Default().SendMessage(mailer.Message{
+		To:          user.Email,
+		Subject:     content.Subject,
+		Text:        content.Text,
+		HTML:        content.HTML,
+		Headers:     headers,
+		Attachments: attachments,
+	})
+}
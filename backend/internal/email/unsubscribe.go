@@ -0,0 +1,62 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"fintrack-backend/internal/auth"
+	"fintrack-backend/internal/models"
+)
+
+// ErrInvalidUnsubscribeToken is returned for tampered or malformed unsubscribe tokens
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+// UnsubscribeToken returns a signed token that unsubscribes userID from list.
+// Tokens are stateless and do not expire, so old emails keep working.
+func UnsubscribeToken(userID primitive.ObjectID, list string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(userID.Hex() + ":" + list))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(unsubscribeSignature(payload))
+}
+
+// ParseUnsubscribeToken verifies token and returns the user and list it is for
+func ParseUnsubscribeToken(token string) (primitive.ObjectID, string, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return primitive.NilObjectID, "", ErrInvalidUnsubscribeToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, unsubscribeSignature(payload)) {
+		return primitive.NilObjectID, "", ErrInvalidUnsubscribeToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return primitive.NilObjectID, "", ErrInvalidUnsubscribeToken
+	}
+	id, list, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return primitive.NilObjectID, "", ErrInvalidUnsubscribeToken
+	}
+	userID, err := primitive.ObjectIDFromHex(id)
+	if err != nil || !models.DefaultEmailPreferences(userID).Subscribed(list) {
+		return primitive.NilObjectID, "", ErrInvalidUnsubscribeToken
+	}
+	return userID, list, nil
+}
+
+// UnsubscribeURL is the link placed in emails sent to list
+func UnsubscribeURL(userID primitive.ObjectID, list string) string {
+	return APIURL() + "/api/email/unsubscribe?token=" + url.QueryEscape(UnsubscribeToken(userID, list))
+}
+
+func unsubscribeSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, auth.GetSecret())
+	mac.Write([]byte("unsubscribe:" + payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,53 @@
+package email
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"fintrack-backend/internal/models"
+)
+
+func TestUnsubscribeTokenRoundTrip(t *testing.T) {
+	userID := primitive.NewObjectID()
+	for _, list := range []string{models.EmailListWeeklyDigest, models.EmailListBudgetAlerts, models.EmailListMonthlyStatements} {
+		gotUser, gotList, err := ParseUnsubscribeToken(UnsubscribeToken(userID, list))
+		if err != nil || gotUser != userID || gotList != list {
+			t.Errorf("round trip of %s = %v, %q, %v", list, gotUser, gotList, err)
+		}
+	}
+}
+
+func TestParseUnsubscribeTokenRejectsTampering(t *testing.T) {
+	userID := primitive.NewObjectID()
+	token := UnsubscribeToken(userID, models.EmailListWeeklyDigest)
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// Same signature on a payload for another user
+	other := UnsubscribeToken(primitive.NewObjectID(), models.EmailListWeeklyDigest)
+	otherPayload, _, _ := strings.Cut(other, ".")
+
+	flipped := []byte(signature)
+	if flipped[0] == 'A' {
+		flipped[0] = 'B'
+	} else {
+		flipped[0] = 'A'
+	}
+
+	tests := map[string]string{
+		"empty":             "",
+		"no signature":      payload,
+		"swapped payload":   otherPayload + "." + signature,
+		"altered signature": payload + "." + string(flipped),
+		"truncated":         token[:len(token)-2],
+		"not base64":        "!!!." + signature,
+		"unknown list":      UnsubscribeToken(userID, "marketing"),
+		"extra separator":   token + ".x",
+	}
+	for name, tok := range tests {
+		if _, _, err := ParseUnsubscribeToken(tok); err != ErrInvalidUnsubscribeToken {
+			t.Errorf("%s: err = %v, want ErrInvalidUnsubscribeToken", name, err)
+		}
+	}
+}
@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+var validAccountTypes = map[string]bool{
+	"checking": true, "savings": true, "cash": true, "credit": true, "investment": true, "loan": true,
+}
+
+// accountBalances returns the user's accounts and their balances (opening balance
+// plus every transaction booked against the account, transfers included)
+func accountBalances(ctx context.Context, userID primitive.ObjectID) ([]models.Account, map[primitive.ObjectID]float64, error) {
+	cursor, err := db.Client.Database("fintrack").Collection("accounts").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var accounts []models.Account
+	if err = cursor.All(ctx, &accounts); err != nil {
+		return nil, nil, err
+	}
+
+	balances := make(map[primitive.ObjectID]float64)
+	if len(accounts) == 0 {
+		return accounts, balances, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "user_id", Value: userID},
+			{Key: "account_id", Value: bson.D{{Key: "$exists", Value: true}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$account_id"},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+		}}},
+	}
+
+	cursor, err = db.Client.Database("fintrack").Collection("transactions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var totals []struct {
+		AccountID primitive.ObjectID `bson:"_id"`
+		Total     float64            `bson:"total"`
+	}
+	if err = cursor.All(ctx, &totals); err != nil {
+		return nil, nil, err
+	}
+
+	for _, a := range accounts {
+		balances[a.ID] = a.OpeningBalance
+	}
+	for _, t := range totals {
+		if _, ok := balances[t.AccountID]; ok {
+			balances[t.AccountID] += t.Total
+		}
+	}
+	return accounts, balances, nil
+}
+
+// ownsAccount reports whether the account exists and belongs to the user
+func ownsAccount(ctx context.Context, userID, accountID primitive.ObjectID) bool {
+	count, err := db.Client.Database("fintrack").Collection("accounts").CountDocuments(ctx, bson.M{"_id": accountID, "user_id": userID})
+	return err == nil && count > 0
+}
+
+// goalBalanceFromAccounts sums the value of a goal's account links
+func goalBalanceFromAccounts(goal models.Goal, balances map[primitive.ObjectID]float64) float64 {
+	total := 0.0
+	for _, link := range goal.Accounts {
+		total += link.Value(balances[link.AccountID])
+	}
+	return math.Round(total*100) / 100
+}
+
+// accountAllocations returns how much of each account balance is backing goals
+func accountAllocations(goals []models.Goal, balances map[primitive.ObjectID]float64) map[primitive.ObjectID]float64 {
+	allocated := make(map[primitive.ObjectID]float64)
+	for _, g := range goals {
+		for _, link := range g.Accounts {
+			allocated[link.AccountID] += link.Value(balances[link.AccountID])
+		}
+	}
+	return allocated
+}
+
+// validateGoalAccountLinks checks that the links reference the user's accounts and
+// that, together with the other goals' links, no account is over-allocated
+func validateGoalAccountLinks(ctx context.Context, userID, goalID primitive.ObjectID, links []models.GoalAccountLink) (string, error) {
+	accounts, balances, err := accountBalances(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	names := make(map[primitive.ObjectID]string)
+	for _, a := range accounts {
+		names[a.ID] = a.Name
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("goals").Find(ctx, bson.M{
+		"user_id":    userID,
+		"_id":        bson.M{"$ne": goalID},
+		"accounts.0": bson.M{"$exists": true},
+	})
+	if err != nil {
+		return "", err
+	}
+	var others []models.Goal
+	if err = cursor.All(ctx, &others); err != nil {
+		return "", err
+	}
+
+	percentages := make(map[primitive.ObjectID]float64)
+	for _, g := range others {
+		for _, link := range g.Accounts {
+			percentages[link.AccountID] += link.Percentage
+		}
+	}
+	allocated := accountAllocations(others, balances)
+
+	seen := make(map[primitive.ObjectID]bool)
+	for _, link := range links {
+		if _, ok := names[link.AccountID]; !ok {
+			return "Account not found", nil
+		}
+		if seen[link.AccountID] {
+			return "Each account can only be linked once per goal", nil
+		}
+		seen[link.AccountID] = true
+
+		if (link.Percentage > 0) == (link.Amount > 0) {
+			return "Each link needs either a percentage or an amount", nil
+		}
+		if link.Percentage > 100 || link.Percentage < 0 || link.Amount < 0 {
+			return "Invalid link percentage or amount", nil
+		}
+
+		balance := balances[link.AccountID]
+		percentages[link.AccountID] += link.Percentage
+		allocated[link.AccountID] += link.Value(balance)
+
+		if percentages[link.AccountID] > 100 {
+			return fmt.Sprintf("Goals would use more than 100%% of %s", names[link.AccountID]), nil
+		}
+		if allocated[link.AccountID] > balance+0.005 {
+			return fmt.Sprintf("Goal allocations exceed the balance of %s (%.2f)", names[link.AccountID], balance), nil
+		}
+	}
+	return "", nil
+}
+
+// GetAccounts lists the user's accounts with balances and goal allocations
+func GetAccounts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	accounts, balances, err := accountBalances(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	var goals []models.Goal
+	cursor, err := db.Client.Database("fintrack").Collection("goals").Find(ctx, bson.M{"user_id": userObjectID, "accounts.0": bson.M{"$exists": true}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goals"})
+		return
+	}
+	if err = cursor.All(ctx, &goals); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse goals"})
+		return
+	}
+	allocated := accountAllocations(goals, balances)
+
+	summaries := []models.AccountSummary{}
+	for _, a := range accounts {
+		balance := math.Round(balances[a.ID]*100) / 100
+		alloc := math.Round(allocated[a.ID]*100) / 100
+		summaries = append(summaries, models.AccountSummary{
+			Account:     a,
+			Balance:     balance,
+			Allocated:   alloc,
+			Unallocated: math.Round((balance-alloc)*100) / 100,
+		})
+	}
+
+	c.JSON(http.StatusOK, summaries)
+}
+
+// CreateAccount adds an account
+func CreateAccount(c *gin.Context) {
+	var account models.Account
+	if err := c.ShouldBindJSON(&account); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if account.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+	if account.Type == "" {
+		account.Type = "checking"
+	}
+	if !validAccountTypes[account.Type] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account type"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	account.ID = primitive.NewObjectID()
+	account.UserID, _ = primitive.ObjectIDFromHex(userID.(string))
+	account.CreatedAt = time.Now()
+	account.UpdatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.Client.Database("fintrack").Collection("accounts").InsertOne(ctx, account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// UpdateAccount edits an account's name, type and opening balance
+func UpdateAccount(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var input models.Account
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Name == "" || !validAccountTypes[input.Type] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name and a valid type are required"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":            input.Name,
+			"type":            input.Type,
+			"opening_balance": input.OpeningBalance,
+			"updated_at":      time.Now(),
+		},
+	}
+
+	result, err := db.Client.Database("fintrack").Collection("accounts").UpdateOne(ctx, bson.M{"_id": id, "user_id": userObjectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	// The opening balance counts towards goals backed by this account
+	go checkAccountGoalMilestones(userObjectID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account updated"})
+}
+
+// DeleteAccount removes an account that has no transactions and backs no goal
+func DeleteAccount(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	inUse, err := db.Client.Database("fintrack").Collection("transactions").CountDocuments(ctx, bson.M{"user_id": userObjectID, "account_id": id})
+	if err == nil && inUse == 0 {
+		inUse, err = db.Client.Database("fintrack").Collection("goals").CountDocuments(ctx, bson.M{"user_id": userObjectID, "accounts.account_id": id})
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check account usage"})
+		return
+	}
+	if inUse > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account has transactions or backs a goal"})
+		return
+	}
+
+	result, err := db.Client.Database("fintrack").Collection("accounts").DeleteOne(ctx, bson.M{"_id": id, "user_id": userObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+}
+
+// CreateTransfer moves money between two accounts as a pair of "transfer" transactions
+func CreateTransfer(c *gin.Context) {
+	var input struct {
+		FromAccountID primitive.ObjectID `json:"from_account_id" binding:"required"`
+		ToAccountID   primitive.ObjectID `json:"to_account_id" binding:"required"`
+		Amount        float64            `json:"amount" binding:"required,gt=0"`
+		Date          time.Time          `json:"date"`
+		Description   string             `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.FromAccountID == input.ToAccountID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot transfer to the same account"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := db.Client.Database("fintrack").Collection("accounts").CountDocuments(ctx, bson.M{
+		"user_id": userObjectID,
+		"_id":     bson.M{"$in": bson.A{input.FromAccountID, input.ToAccountID}},
+	})
+	if err != nil || count != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found"})
+		return
+	}
+
+	if input.Date.IsZero() {
+		input.Date = time.Now()
+	}
+	if input.Description == "" {
+		input.Description = "Transfer"
+	}
+
+	transferID := primitive.NewObjectID()
+	from, to := input.FromAccountID, input.ToAccountID
+	legs := []interface{}{
+		models.Transaction{ID: primitive.NewObjectID(), UserID: userObjectID, Date: input.Date, Description: input.Description,
+			Category: "Transfer", Amount: -input.Amount, Type: "transfer", AccountID: &from, TransferID: &transferID, CreatedAt: time.Now()},
+		models.Transaction{ID: primitive.NewObjectID(), UserID: userObjectID, Date: input.Date, Description: input.Description,
+			Category: "Transfer", Amount: input.Amount, Type: "transfer", AccountID: &to, TransferID: &transferID, CreatedAt: time.Now()},
+	}
+
+	_, err = db.Client.Database("fintrack").Collection("transactions").InsertMany(ctx, legs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transfer"})
+		return
+	}
+	go checkAccountGoalMilestones(userObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{"transfer_id": transferID, "transactions": legs})
+}
+
+// updateTransferLegs applies an edit of one transfer leg to both legs, so the
+// pair stays balanced: each leg keeps its direction and account
+func updateTransferLegs(ctx context.Context, userID, transferID primitive.ObjectID, date time.Time, description string, amount float64) error {
+	amount = math.Abs(amount)
+	_, err := db.Client.Database("fintrack").Collection("transactions").UpdateMany(ctx,
+		bson.M{"user_id": userID, "transfer_id": transferID},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"date":        date,
+			"description": description,
+			"amount":      bson.M{"$cond": bson.A{bson.M{"$lt": bson.A{"$amount", 0}}, -amount, amount}},
+		}}}})
+	return err
+}
+
+// SetGoalAccounts links a goal to accounts; an empty list returns the goal to its
+// contribution ledger
+func SetGoalAccounts(c *gin.Context) {
+	goalID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		Accounts []models.GoalAccountLink `json:"accounts"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	msg, err := validateGoalAccountLinks(ctx, userObjectID, goalID, input.Accounts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate accounts"})
+		return
+	}
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var update bson.M
+	if len(input.Accounts) == 0 {
+		update = bson.M{"$unset": bson.M{"accounts": ""}}
+	} else {
+		update = bson.M{"$set": bson.M{"accounts": input.Accounts}}
+	}
+
+	result, err := db.Client.Database("fintrack").Collection("goals").UpdateOne(ctx, bson.M{"_id": goalID, "user_id": userObjectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update goal"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+		return
+	}
+	if len(input.Accounts) > 0 {
+		go checkAccountGoalMilestones(userObjectID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Goal accounts updated", "accounts": input.Accounts})
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+	"fintrack-backend/internal/notify"
+)
+
+const (
+	// Expense history used as the baseline for payee checks
+	anomalyHistoryDays = 365
+	// Prior charges needed before a payee has a meaningful baseline
+	anomalyMinPayeeHistory = 4
+	// Standard deviations above the mean that count as unusual
+	anomalyPayeeZScore    = 3.0
+	anomalyCategoryZScore = 2.0
+	// The amount must also exceed the baseline by this factor, so low-variance
+	// payees do not flag tiny differences
+	anomalyMinRatio = 1.5
+	// A first-time payee is flagged from this amount and this multiple of the
+	// user's median expense
+	newMerchantMinAmount       = 100.0
+	newMerchantExpenseMultiple = 3.0
+	// Budget periods averaged for category spikes, and how many must have spending
+	anomalyCategoryMonths    = 6
+	anomalyCategoryMinMonths = 3
+	// Smallest excess over the category average worth reporting
+	anomalyCategoryMinExcess = 25.0
+)
+
+// meanStdDev returns the mean and population standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// unusuallyHigh reports whether value stands out from a baseline with the given mean
+// and standard deviation, and returns its z-score (0 when the baseline has no spread)
+func unusuallyHigh(value, mean, stdDev, minZ float64) (bool, float64) {
+	if mean <= 0 || value < mean*anomalyMinRatio {
+		return false, 0
+	}
+	if stdDev == 0 {
+		return true, 0
+	}
+	z := (value - mean) / stdDev
+	return z >= minZ, math.Round(z*10) / 10
+}
+
+// payeeAnomaly checks an expense against the earlier charges at the same payee, or
+// against the user's typical expense when the payee is new
+func payeeAnomaly(tx models.Transaction, merchant string, payeeHistory []float64, typicalExpense float64) *models.Anomaly {
+	amount := math.Abs(tx.Amount)
+	txID := tx.ID
+	anomaly := &models.Anomaly{
+		UserID:        tx.UserID,
+		Key:           tx.ID.Hex(),
+		TransactionID: &txID,
+		Category:      tx.Category,
+		Merchant:      merchant,
+		Amount:        amount,
+	}
+
+	if len(payeeHistory) == 0 {
+		threshold := math.Max(newMerchantMinAmount, typicalExpense*newMerchantExpenseMultiple)
+		if amount < threshold {
+			return nil
+		}
+		anomaly.Kind = models.AnomalyNewMerchant
+		anomaly.Expected = math.Round(typicalExpense*100) / 100
+		anomaly.Message = fmt.Sprintf("First payment to %s is %.2f, well above your typical expense of %.2f",
+			tx.Description, amount, typicalExpense)
+		return anomaly
+	}
+
+	if len(payeeHistory) < anomalyMinPayeeHistory {
+		return nil
+	}
+	mean, stdDev := meanStdDev(payeeHistory)
+	unusual, z := unusuallyHigh(amount, mean, stdDev, anomalyPayeeZScore)
+	if !unusual {
+		return nil
+	}
+	anomaly.Kind = models.AnomalyLargeTransaction
+	anomaly.Expected = math.Round(mean*100) / 100
+	anomaly.Score = z
+	anomaly.Message = fmt.Sprintf("%.2f at %s is unusually large; you usually pay about %.2f",
+		amount, tx.Description, mean)
+	return anomaly
+}
+
+// categorySpikeAnomaly compares a category's spending in the current period with the
+// previous periods (oldest first). Periods before the first spending are ignored.
+func categorySpikeAnomaly(userID primitive.ObjectID, category string, periodStart time.Time, spent float64, previous []float64) *models.Anomaly {
+	for len(previous) > 0 && previous[0] == 0 {
+		previous = previous[1:]
+	}
+	if len(previous) < anomalyCategoryMinMonths {
+		return nil
+	}
+
+	mean, stdDev := meanStdDev(previous)
+	unusual, z := unusuallyHigh(spent, mean, stdDev, anomalyCategoryZScore)
+	if !unusual || spent-mean < anomalyCategoryMinExcess {
+		return nil
+	}
+
+	period := periodStart.Format("2006-01")
+	return &models.Anomaly{
+		UserID:   userID,
+		Kind:     models.AnomalyCategorySpike,
+		Key:      category + "|" + period,
+		Category: category,
+		Period:   period,
+		Amount:   math.Round(spent*100) / 100,
+		Expected: math.Round(mean*100) / 100,
+		Score:    z,
+		Message: fmt.Sprintf("%s spending in %s is %.2f, %.0f%% above your %d-month average of %.2f",
+			category, periodStart.Format("January 2006"), spent, (spent/mean-1)*100, len(previous), mean),
+	}
+}
+
+// detectTransactionAnomalies runs the payee and category checks for an expense
+func detectTransactionAnomalies(ctx context.Context, tx models.Transaction) ([]models.Anomaly, error) {
+	if tx.Type != "expense" {
+		return nil, nil
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("transactions").Find(ctx, bson.M{
+		"user_id": tx.UserID,
+		"type":    "expense",
+		"_id":     bson.M{"$ne": tx.ID},
+		"date":    bson.M{"$gte": tx.Date.AddDate(0, 0, -anomalyHistoryDays), "$lte": tx.Date},
+	}, options.Find().SetProjection(bson.M{"description": 1, "amount": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var history []models.Transaction
+	if err = cursor.All(ctx, &history); err != nil {
+		return nil, err
+	}
+
+	merchant := normalizeMerchant(tx.Description)
+	var payeeHistory, allExpenses []float64
+	for _, h := range history {
+		allExpenses = append(allExpenses, math.Abs(h.Amount))
+		if merchant != "" && normalizeMerchant(h.Description) == merchant {
+			payeeHistory = append(payeeHistory, math.Abs(h.Amount))
+		}
+	}
+
+	var anomalies []models.Anomaly
+	// Without any history every payee would be "new"
+	if merchant != "" && len(allExpenses) > 0 {
+		if a := payeeAnomaly(tx, merchant, payeeHistory, median(allExpenses)); a != nil {
+			anomalies = append(anomalies, *a)
+		}
+	}
+
+	start, end := budgetPeriod(tx.Date)
+	spent, err := categorySpending(ctx, tx.UserID, tx.Category, start, end)
+	if err != nil {
+		return nil, err
+	}
+	previous := make([]float64, 0, anomalyCategoryMonths)
+	for i := anomalyCategoryMonths; i >= 1; i-- {
+		s, err := categorySpending(ctx, tx.UserID, tx.Category, start.AddDate(0, -i, 0), start.AddDate(0, -i+1, 0))
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, s)
+	}
+	if a := categorySpikeAnomaly(tx.UserID, tx.Category, start, spent, previous); a != nil {
+		anomalies = append(anomalies, *a)
+	}
+
+	return anomalies, nil
+}
+
+// storeAnomaly records a finding unless it was already recorded, and notifies the
+// user about new ones
+func storeAnomaly(ctx context.Context, anomaly models.Anomaly) error {
+	anomaly.ID = primitive.NewObjectID()
+	anomaly.Status = models.AnomalyOpen
+	anomaly.DetectedAt = time.Now()
+
+	// $setOnInsert + upsert so that reviewed or dismissed findings stay as they are
+	filter := bson.M{"user_id": anomaly.UserID, "kind": anomaly.Kind, "key": anomaly.Key}
+	result, err := db.Client.Database("fintrack").Collection("anomalies").UpdateOne(ctx, filter,
+		bson.M{"$setOnInsert": anomaly}, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return err
+	}
+	if result.UpsertedCount > 0 {
+		notify.DispatchAsync(notify.Message{
+			UserID: anomaly.UserID,
+			Type:   "spending_anomaly",
+			Title:  "Unusual spending detected",
+			Body:   anomaly.Message,
+			Data: map[string]interface{}{
+				"anomaly_id": anomaly.ID.Hex(),
+				"kind":       anomaly.Kind,
+				"amount":     anomaly.Amount,
+				"expected":   anomaly.Expected,
+			},
+		})
+	}
+	return nil
+}
+
+// checkTransactionAnomalies runs the anomaly detector for a saved transaction.
+// It is meant to run in the background after a transaction is created.
+func checkTransactionAnomalies(tx models.Transaction) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	anomalies, err := detectTransactionAnomalies(ctx, tx)
+	if err != nil {
+		log.Printf("Anomaly detection for transaction %s failed: %v", tx.ID.Hex(), err)
+		return
+	}
+	for _, a := range anomalies {
+		if err := storeAnomaly(ctx, a); err != nil {
+			log.Printf("Anomaly detection: failed to store %s finding: %v", a.Kind, err)
+		}
+	}
+}
+
+// RunAnomalyDetection checks the expenses created or dated in the last day for every
+// user, catching transactions that did not go through CreateTransaction. It is run
+// nightly by the scheduler.
+func RunAnomalyDetection(ctx context.Context) error {
+	since := time.Now().Add(-26 * time.Hour)
+
+	cursor, err := db.Client.Database("fintrack").Collection("transactions").Find(ctx, bson.M{
+		"type": "expense",
+		"$or": bson.A{
+			bson.M{"created_at": bson.M{"$gte": since}},
+			bson.M{"date": bson.M{"$gte": since}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var tx models.Transaction
+		if err := cursor.Decode(&tx); err != nil {
+			return err
+		}
+		anomalies, err := detectTransactionAnomalies(ctx, tx)
+		if err != nil {
+			log.Printf("Anomaly detection for transaction %s failed: %v", tx.ID.Hex(), err)
+			continue
+		}
+		for _, a := range anomalies {
+			if err := storeAnomaly(ctx, a); err != nil {
+				log.Printf("Anomaly detection: failed to store %s finding: %v", a.Kind, err)
+			}
+		}
+	}
+	return cursor.Err()
+}
+
+// GetAnomalies lists detected anomalies, newest first. ?status= filters by review
+// state (default "open"; "all" for every finding).
+func GetAnomalies(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	filter := bson.M{"user_id": userObjectID}
+	if status := c.DefaultQuery("status", models.AnomalyOpen); status != "all" {
+		filter["status"] = status
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "detected_at", Value: -1}})
+	findOptions.SetLimit(200)
+
+	cursor, err := db.Client.Database("fintrack").Collection("anomalies").Find(ctx, filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch anomalies"})
+		return
+	}
+
+	anomalies := []models.Anomaly{}
+	if err = cursor.All(ctx, &anomalies); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse anomalies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, anomalies)
+}
+
+// UpdateAnomalyStatus marks a finding as reviewed or dismissed, or reopens it
+func UpdateAnomalyStatus(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		Status string `json:"status" binding:"required,oneof=open reviewed dismissed"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"status": input.Status, "reviewed_at": time.Now()}}
+	if input.Status == models.AnomalyOpen {
+		update = bson.M{"$set": bson.M{"status": input.Status}, "$unset": bson.M{"reviewed_at": ""}}
+	}
+
+	result, err := db.Client.Database("fintrack").Collection("anomalies").UpdateOne(ctx, bson.M{"_id": id, "user_id": userObjectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update anomaly"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Anomaly not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Anomaly updated", "status": input.Status})
+}
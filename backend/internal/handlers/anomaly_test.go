@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"fintrack-backend/internal/models"
+)
+
+func TestMeanStdDev(t *testing.T) {
+	mean, stdDev := meanStdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if mean != 5 || stdDev != 2 {
+		t.Errorf("meanStdDev = %v, %v; want 5, 2", mean, stdDev)
+	}
+	if mean, stdDev := meanStdDev(nil); mean != 0 || stdDev != 0 {
+		t.Errorf("meanStdDev(nil) = %v, %v", mean, stdDev)
+	}
+}
+
+func TestUnusuallyHigh(t *testing.T) {
+	tests := []struct {
+		name                      string
+		value, mean, stdDev, minZ float64
+		unusual                   bool
+		z                         float64
+	}{
+		{"no baseline", 100, 0, 0, 3, false, 0},
+		{"below the ratio despite a high z-score", 14, 10, 0.5, 3, false, 0},
+		{"no spread at the ratio", 15, 10, 0, 3, true, 0},
+		{"no spread below the ratio", 14.9, 10, 0, 3, false, 0},
+		{"below the z-score", 40, 20, 10, 3, false, 2},
+		{"at the z-score", 50, 20, 10, 3, true, 3},
+		{"lower z-score gate", 30, 20, 5, 2, true, 2},
+		{"rounded z-score", 45, 20, 7, 3, true, 3.6},
+	}
+	for _, tt := range tests {
+		unusual, z := unusuallyHigh(tt.value, tt.mean, tt.stdDev, tt.minZ)
+		if unusual != tt.unusual || z != tt.z {
+			t.Errorf("%s: unusuallyHigh = %v, %v; want %v, %v", tt.name, unusual, z, tt.unusual, tt.z)
+		}
+	}
+}
+
+func TestPayeeAnomaly(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		history  []float64
+		typical  float64
+		kind     string // "" when nothing is flagged
+		expected float64
+		score    float64
+	}{
+		{"new payee below the minimum", 99, nil, 20, "", 0, 0},
+		{"new payee at the minimum", 100, nil, 20, models.AnomalyNewMerchant, 20, 0},
+		{"new payee below the expense multiple", 149, nil, 50, "", 0, 0},
+		{"new payee at the expense multiple", 150, nil, 50, models.AnomalyNewMerchant, 50, 0},
+		{"too little history", 500, []float64{10, 10, 10}, 20, "", 0, 0},
+		{"constant payee", 15, []float64{10, 10, 10, 10}, 20, models.AnomalyLargeTransaction, 10, 0},
+		{"constant payee below the ratio", 14, []float64{10, 10, 10, 10}, 20, "", 0, 0},
+		{"varying payee above the z-score", 15, []float64{10, 12, 8, 10}, 20, models.AnomalyLargeTransaction, 10, 3.5},
+		{"spread payee below the z-score", 45, []float64{10, 30, 10, 30}, 20, "", 0, 0},
+		{"spread payee at the z-score", 50, []float64{10, 30, 10, 30}, 20, models.AnomalyLargeTransaction, 20, 3},
+	}
+	for _, tt := range tests {
+		tx := models.Transaction{ID: primitive.NewObjectID(), Description: "Hardware Store", Category: "Home", Amount: -tt.amount}
+		got := payeeAnomaly(tx, "hardware store", tt.history, tt.typical)
+		if tt.kind == "" {
+			if got != nil {
+				t.Errorf("%s: flagged %+v", tt.name, got)
+			}
+			continue
+		}
+		if got == nil {
+			t.Errorf("%s: not flagged", tt.name)
+			continue
+		}
+		if got.Kind != tt.kind || got.Expected != tt.expected || got.Score != tt.score || got.Amount != tt.amount {
+			t.Errorf("%s: anomaly = %+v, want kind %s, expected %v, score %v", tt.name, got, tt.kind, tt.expected, tt.score)
+		}
+		if got.Key != tx.ID.Hex() || got.TransactionID == nil || *got.TransactionID != tx.ID {
+			t.Errorf("%s: anomaly is not keyed by the transaction: %+v", tt.name, got)
+		}
+	}
+}
+
+func TestCategorySpikeAnomaly(t *testing.T) {
+	userID := primitive.NewObjectID()
+	periodStart := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		spent    float64
+		previous []float64
+		flagged  bool
+		months   int // Periods averaged, as stated in the message
+	}{
+		{"leading zero months dropped", 300, []float64{0, 0, 0, 100, 100, 100}, true, 3},
+		{"too few months after dropping zeros", 300, []float64{0, 0, 0, 0, 100, 100}, false, 0},
+		{"zero months after the first spending count", 200, []float64{100, 0, 100, 0, 100, 0}, true, 6},
+		{"excess below the minimum", 40, []float64{20, 20, 20}, false, 0},
+		{"excess at the minimum", 45, []float64{20, 20, 20}, true, 3},
+		{"below the z-score", 240, []float64{100, 200, 100, 200}, false, 0},
+		{"at the z-score", 250, []float64{100, 200, 100, 200}, true, 4},
+		{"below the ratio", 140, []float64{100, 100, 100}, false, 0},
+		{"no spending before", 500, []float64{0, 0, 0, 0, 0, 0}, false, 0},
+	}
+	for _, tt := range tests {
+		got := categorySpikeAnomaly(userID, "Food", periodStart, tt.spent, tt.previous)
+		if (got != nil) != tt.flagged {
+			t.Errorf("%s: flagged = %v, want %v", tt.name, got != nil, tt.flagged)
+			continue
+		}
+		if got == nil {
+			continue
+		}
+		if got.Kind != models.AnomalyCategorySpike || got.Key != "Food|2026-06" || got.Period != "2026-06" || got.UserID != userID {
+			t.Errorf("%s: anomaly = %+v", tt.name, got)
+		}
+		if want := fmt.Sprintf("%d-month average", tt.months); !strings.Contains(got.Message, want) {
+			t.Errorf("%s: message %q does not mention a %d-month average", tt.name, got.Message, tt.months)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/oauth2"
+
+	"fintrack-backend/internal/auth"
+)
+
+const appleAuthorizeURL = "https://appleid.apple.com/auth/authorize"
+
+// appleVerifier checks identity tokens from Sign in with Apple. It is a variable so
+// it can be pointed at locally generated keys.
+var appleVerifier *auth.AppleVerifier
+
+func getAppleVerifier() *auth.AppleVerifier {
+	if appleVerifier == nil {
+		appleVerifier = &auth.AppleVerifier{
+			ClientID: os.Getenv("APPLE_CLIENT_ID"), // The Services ID
+			Keys:     auth.NewJWKSKeySource(auth.AppleKeysURL),
+		}
+	}
+	return appleVerifier
+}
+
+// AppleLogin redirects to Sign in with Apple. Apple posts the result back to
+// AppleCallback, with an identity token bound to this request by its nonce. With a
+// link_token from LinkProvider the Apple ID is linked to that user instead.
+func AppleLogin(c *gin.Context) {
+	linkUserID, err := linkIntent(c, "apple")
+	if err != nil {
+		redirectOAuthResult(c.Request.Context(), c, primitive.NilObjectID, "invalid_link")
+		return
+	}
+	state, verifier := startOAuth(c, "apple", linkUserID)
+
+	q := url.Values{}
+	q.Set("client_id", os.Getenv("APPLE_CLIENT_ID"))
+	q.Set("redirect_uri", oauthRedirectURL("apple"))
+	q.Set("response_type", "code id_token")
+	q.Set("response_mode", "form_post") // Required by Apple when asking for name or email
+	q.Set("scope", "name email")
+	q.Set("state", state)
+	q.Set("nonce", oauth2.S256ChallengeFromVerifier(verifier))
+	c.Redirect(http.StatusTemporaryRedirect, appleAuthorizeURL+"?"+q.Encode())
+}
+
+// AppleCallback handles Apple's form_post and redirects to the web app like
+// GoogleCallback does. The identity token is verified directly, so no client
+// secret is needed to exchange the authorization code.
+func AppleCallback(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	verifier, linkUserID, err := finishOAuth(c, "apple", c.PostForm("state"))
+	if err != nil {
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "invalid_state")
+		return
+	}
+	if c.PostForm("error") != "" {
+		// e.g. user_cancelled_authorize
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "access_denied")
+		return
+	}
+
+	identity, err := getAppleVerifier().Verify(ctx, c.PostForm("id_token"), oauth2.S256ChallengeFromVerifier(verifier))
+	if err != nil {
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "invalid_token")
+		return
+	}
+
+	// Apple sends the user's name only the first time they authorize the app, and
+	// never in the identity token
+	var appleUser struct {
+		Name struct {
+			FirstName string `json:"firstName"`
+			LastName  string `json:"lastName"`
+		} `json:"name"`
+	}
+	if user := c.PostForm("user"); user != "" {
+		json.Unmarshal([]byte(user), &appleUser)
+	}
+	name := strings.TrimSpace(appleUser.Name.FirstName + " " + appleUser.Name.LastName)
+	if name == "" {
+		name = "Apple user"
+		if at := strings.Index(identity.Email, "@"); at > 0 && !identity.PrivateRelay {
+			name = identity.Email[:at]
+		}
+	}
+
+	// A Hide My Email relay address is unique to this app, so it only matches an
+	// account that was created through Apple
+	finishProviderLogin(ctx, c, providerProfile{
+		Provider:      "apple",
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified || identity.PrivateRelay,
+		PrivateEmail:  identity.PrivateRelay,
+		Name:          name,
+	}, linkUserID)
+}
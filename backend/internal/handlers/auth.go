@@ -3,7 +3,6 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -16,11 +15,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
-
-	"fintrack-backend/internal/auth"
 )
 
 func Register(c *gin.Context) {
@@ -75,18 +73,16 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// Generate Token
-	token, err := auth.GenerateToken(user.ID.Hex())
+	sendVerificationEmailAsync(user)
+
+	// Generate Tokens
+	tokens, err := startSession(ctx, c, user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "User created successfully",
-		"user":    user,
-		"token":   token,
-	})
+	c.JSON(http.StatusCreated, loginResponse("User created successfully", user, tokens))
 }
 
 func Login(c *gin.Context) {
@@ -109,38 +105,28 @@ func Login(c *gin.Context) {
 
 	var user models.User
 	err := collection.FindOne(ctx, bson.M{"email": input.Email}).Decode(&user)
-	if err != nil {
-		fmt.Println("Login Error: User not found for email:", input.Email) // Debug log
+	if err == mongo.ErrNoDocuments {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
-
-	if user.Provider == "google" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Please log in with Google"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
 		return
 	}
 
-	fmt.Printf("Login Debug - Stored Hash: %s... (len: %d)\n", user.Password[:10], len(user.Password))
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
-	if err != nil {
-		fmt.Println("Login Error: Password mismatch for user:", input.Email) // Debug log
-		fmt.Printf("Login Error Details: %v\n", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+	// Accounts created through a provider have no password until one is set by reset
+	if user.Password == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Please log in with a linked provider, or reset your password to set one"})
 		return
 	}
 
-	// Generate Token
-	token, err := auth.GenerateToken(user.ID.Hex())
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"user":    user,
-		"token":   token,
-	})
+	completeLogin(ctx, c, user, "Login successful")
 }
 
 var googleOauthConfig *oauth2.Config
@@ -148,7 +134,7 @@ var googleOauthConfig *oauth2.Config
 func getGoogleOauthConfig() *oauth2.Config {
 	if googleOauthConfig == nil {
 		googleOauthConfig = &oauth2.Config{
-			RedirectURL:  "http://localhost:8080/api/auth/google/callback",
+			RedirectURL:  oauthRedirectURL("google"),
 			ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
 			ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
 			Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
@@ -158,98 +144,75 @@ func getGoogleOauthConfig() *oauth2.Config {
 	return googleOauthConfig
 }
 
+// GoogleLogin redirects to Google's consent screen with a per-request state and a
+// PKCE challenge, both checked by GoogleCallback. With a link_token from
+// LinkProvider the Google account is linked to that user instead.
 func GoogleLogin(c *gin.Context) {
-	url := getGoogleOauthConfig().AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	linkUserID, err := linkIntent(c, "google")
+	if err != nil {
+		redirectOAuthResult(c.Request.Context(), c, primitive.NilObjectID, "invalid_link")
+		return
+	}
+	state, verifier := startOAuth(c, "google", linkUserID)
+	url := getGoogleOauthConfig().AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
 	c.Redirect(http.StatusTemporaryRedirect, url)
 }
 
+// GoogleCallback completes the Google login and redirects to the web app with a
+// one-time code, exchanged for a session at /auth/exchange, or a link token when
+// the email belongs to an existing account
 func GoogleCallback(c *gin.Context) {
-	code := c.Query("code")
-	token, err := getGoogleOauthConfig().Exchange(context.Background(), code)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	verifier, linkUserID, err := finishOAuth(c, "google", c.Query("state"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Code exchange failed"})
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "invalid_state")
 		return
 	}
-
-	resp, err := http.Get("https://www.googleapis.com/oauth2/v2/userinfo?access_token=" + token.AccessToken)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user info"})
+	if c.Query("error") != "" {
+		// e.g. the user declined consent
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "access_denied")
 		return
 	}
-	defer resp.Body.Close()
 
-	content, err := io.ReadAll(resp.Body)
+	config := getGoogleOauthConfig()
+	token, err := config.Exchange(ctx, c.Query("code"), oauth2.VerifierOption(verifier))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read user info"})
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "exchange_failed")
 		return
 	}
 
-	var googleUser struct {
-		ID    string `json:"id"`
-		Email string `json:"email"`
-		Name  string `json:"name"`
+	resp, err := config.Client(ctx, token).Get("https://www.googleapis.com/oauth2/v2/userinfo")
+	if err != nil {
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "userinfo_failed")
+		return
 	}
+	defer resp.Body.Close()
 
-	if err := json.Unmarshal(content, &googleUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user info"})
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "userinfo_failed")
 		return
 	}
 
-	// Normalize email
-	googleUser.Email = strings.ToLower(googleUser.Email)
-
-	// Check if user exists
-	collection := db.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	var user models.User
-	err = collection.FindOne(ctx, bson.M{"email": googleUser.Email}).Decode(&user)
-
-	if err != nil {
-		// Create new user
-		newUser := models.User{
-			ID:        primitive.NewObjectID(),
-			Name:      googleUser.Name,
-			Email:     googleUser.Email,
-			Provider:  "google",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
-		_, err = collection.InsertOne(ctx, newUser)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user"})
-			return
-		}
-		user = newUser
-	} else {
-		// Update provider if not set (merging accounts strictly by email is risky, but common in simple apps)
-		// Or if provider is different, might want to handle it. For now, assume it's fine.
-		if user.Provider == "" {
-			update := bson.M{"$set": bson.M{"provider": "google", "updated_at": time.Now()}}
-			collection.UpdateOne(ctx, bson.M{"_id": user.ID}, update)
-		}
+	var googleUser struct {
+		ID            string `json:"id"`
+		Email         string `json:"email"`
+		VerifiedEmail bool   `json:"verified_email"`
+		Name          string `json:"name"`
 	}
 
-	// Generate Token
-	jwtToken, err := auth.GenerateToken(user.ID.Hex())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+	if err := json.Unmarshal(content, &googleUser); err != nil || googleUser.ID == "" {
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "userinfo_failed")
 		return
 	}
 
-	// For Google Login, we might want to redirect to frontend with token in query param
-	// OR return JSON if this is called via AJAX (but usually it's a redirect flow)
-	// Assuming frontend handles the callback or we redirect with token:
-	// c.Redirect(http.StatusTemporaryRedirect, "http://localhost:5173/auth/callback?token="+token)
-	// But current implementation returns JSON:
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"user":    user,
-		"token":   jwtToken,
-	})
-}
-
-func AppleLogin(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Apple Login endpoint"})
+	finishProviderLogin(ctx, c, providerProfile{
+		Provider:      "google",
+		Subject:       googleUser.ID,
+		Email:         googleUser.Email,
+		EmailVerified: googleUser.VerifiedEmail,
+		Name:          googleUser.Name,
+	}, linkUserID)
 }
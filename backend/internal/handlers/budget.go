@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"log"
 	"math"
 	"net/http"
 	"time"
@@ -15,7 +16,7 @@ import (
 	"fintrack-backend/internal/models"
 )
 
-// GetBudgetOverview returns the budget summary and category breakdown
+// GetBudgetOverview returns the budget summary and category breakdown (v1, web client shape)
 func GetBudgetOverview(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -27,30 +28,64 @@ func GetBudgetOverview(c *gin.Context) {
 	}
 	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 
+	overview, err := buildBudgetOverview(ctx, userObjectID, time.Now())
+	if err != nil {
+		log.Printf("Budget overview for user %s failed: %v", userObjectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load budget overview"})
+		return
+	}
+
+	c.JSON(http.StatusOK, legacyBudgetOverview(overview))
+}
+
+// GetBudgetOverviewV2 returns the presentation-neutral budget summary
+func GetBudgetOverviewV2(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	overview, err := buildBudgetOverview(ctx, userObjectID, time.Now())
+	if err != nil {
+		log.Printf("Budget overview for user %s failed: %v", userObjectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load budget overview"})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+// budgetStatus classifies a percentage of the limit against the status thresholds
+func budgetStatus(pct float64) string {
+	if pct >= models.DefaultStatusThresholds.Critical {
+		return models.BudgetStatusCritical
+	} else if pct >= models.DefaultStatusThresholds.Warning {
+		return models.BudgetStatusWarning
+	}
+	return models.BudgetStatusOnTrack
+}
+
+// buildBudgetOverview computes the budget summary for the period containing now
+func buildBudgetOverview(ctx context.Context, userObjectID primitive.ObjectID, now time.Time) (*models.BudgetOverview, error) {
 	// 1. Get all Budget Categories for this user
 	var budgets []models.BudgetCategory
 	cursor, err := db.Client.Database("fintrack").Collection("budgets").Find(ctx, bson.M{"user_id": userObjectID})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budgets"})
-		return
+		return nil, fmt.Errorf("fetch budgets: %w", err)
 	}
 	if err = cursor.All(ctx, &budgets); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse budgets"})
-		return
+		return nil, fmt.Errorf("decode budgets: %w", err)
 	}
 
 	// Calculate date range for current month
-	now := time.Now()
-	currentYear, currentMonth := now.Year(), now.Month()
-	startDate := time.Date(currentYear, currentMonth, 1, 0, 0, 0, 0, now.Location())
-	endDate := startDate.AddDate(0, 1, 0)
+	startDate, endDate := budgetPeriod(now)
 
-	// If no budgets exist, return empty list (no seeding)
-	if len(budgets) == 0 {
-		budgets = []models.BudgetCategory{}
-	}
-
-	// 2. Get Transactions for the current month (Expenses only)
+	// 2. Get Transactions for the current month (Expenses and income)
 	var transactions []models.Transaction
 	tCursor, err := db.Client.Database("fintrack").Collection("transactions").Find(ctx, bson.M{
 		"user_id": userObjectID,
@@ -58,32 +93,76 @@ func GetBudgetOverview(c *gin.Context) {
 			"$gte": startDate,
 			"$lt":  endDate,
 		},
-		"type": "expense",
+		"type": bson.M{"$in": bson.A{"expense", "income"}},
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
-		return
+		return nil, fmt.Errorf("fetch transactions: %w", err)
 	}
 	if err = tCursor.All(ctx, &transactions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse transactions"})
-		return
+		return nil, fmt.Errorf("decode transactions: %w", err)
 	}
 
 	// 3. Calculate Stats
 	totalBudgetLimit := 0.0
 	totalSpent := 0.0
+	totalReceived := 0.0
 
-	// Map to store spent amount per category
+	// Maps to store spent / received amount per category
 	categorySpent := make(map[string]float64)
+	categoryReceived := make(map[string]float64)
 	for _, t := range transactions {
 		amt := math.Abs(t.Amount)
+		if t.Type == "income" {
+			categoryReceived[t.Category] += amt
+			totalReceived += amt
+			continue
+		}
 		categorySpent[t.Category] += amt
 		totalSpent += amt
 	}
 
-	var categoryStatuses []models.CategoryStatus
+	// Inputs for the end-of-period forecast
+	daysElapsed, daysInPeriod := periodProgress(now, startDate, endDate)
+	elapsed := float64(daysElapsed) / float64(daysInPeriod)
+
+	historicalShares, err := historicalSpendShares(ctx, userObjectID, startDate, elapsed)
+	if err != nil {
+		return nil, fmt.Errorf("fetch spending history: %w", err)
+	}
+
+	schedules, err := loadRecurringSchedules(ctx, userObjectID, "expense")
+	if err != nil {
+		return nil, fmt.Errorf("fetch recurring schedules: %w", err)
+	}
+	recurring := recurringExpenses(schedules, transactions, startDate, now, endDate)
+
+	categories := []models.BudgetCategoryOverview{}
+	incomeStatuses := []models.IncomeStatus{}
+	projectedTotal := 0.0
+	atRisk := 0
+	totalExpected := 0.0
 
 	for _, b := range budgets {
+		if b.IsIncome() {
+			received := categoryReceived[b.Name]
+			totalExpected += b.Limit
+
+			pct := 0.0
+			if b.Limit > 0 {
+				pct = (received / b.Limit) * 100
+			}
+
+			incomeStatuses = append(incomeStatuses, models.IncomeStatus{
+				ID:         b.ID.Hex(),
+				Name:       b.Name,
+				Expected:   b.Limit,
+				Received:   received,
+				Percentage: math.Round(pct),
+				Icon:       b.Icon,
+			})
+			continue
+		}
+
 		spent := categorySpent[b.Name]
 		totalBudgetLimit += b.Limit
 
@@ -92,36 +171,23 @@ func GetBudgetOverview(c *gin.Context) {
 			pct = (spent / b.Limit) * 100
 		}
 
-		// Determine Status based on percentage used
-		status := "ON TRACK"
-		statusColor := "text-green-600"
-
-		if pct >= 100 {
-			status = "CRITICAL"
-			statusColor = "text-red-600"
-		} else if pct >= 80 {
-			status = "WARNING"
-			statusColor = "text-orange-600"
+		forecast := forecastCategory(spent, b.Limit, elapsed, historicalShares[b.Name], recurring[b.Name])
+		projectedTotal += forecast.ProjectedSpend
+		if forecast.ProjectedOverLimit {
+			atRisk++
 		}
 
-		// Generate UI colors based on category color
-		// Assuming b.Color is like "orange", "blue", etc.
-		iconBg := fmt.Sprintf("bg-%s-100", b.Color)
-		iconColor := fmt.Sprintf("text-%s-600", b.Color)
-		progressColor := fmt.Sprintf("bg-%s-500", b.Color)
-
-		categoryStatuses = append(categoryStatuses, models.CategoryStatus{
-			ID:            b.ID.Hex(),
-			Name:          b.Name,
-			Limit:         b.Limit,
-			Spent:         spent,
-			Percentage:    math.Round(pct),
-			Status:        status,
-			StatusColor:   statusColor,
-			Icon:          b.Icon,
-			IconBg:        iconBg,
-			IconColor:     iconColor,
-			ProgressColor: progressColor,
+		categories = append(categories, models.BudgetCategoryOverview{
+			ID:              b.ID.Hex(),
+			Name:            b.Name,
+			Limit:           b.Limit,
+			Spent:           spent,
+			Percentage:      math.Round(pct),
+			Status:          budgetStatus(pct),
+			Icon:            b.Icon,
+			Color:           b.Color,
+			AlertThresholds: b.AlertThresholds(),
+			Forecast:        forecast,
 		})
 	}
 
@@ -131,15 +197,99 @@ func GetBudgetOverview(c *gin.Context) {
 		overallPct = (totalSpent / totalBudgetLimit) * 100
 	}
 
-	response := models.BudgetOverviewResponse{
+	// Savings rates relative to income
+	plannedSavingsRate := 0.0
+	if totalExpected > 0 {
+		plannedSavingsRate = ((totalExpected - totalBudgetLimit) / totalExpected) * 100
+	}
+	actualSavingsRate := 0.0
+	if totalReceived > 0 {
+		actualSavingsRate = ((totalReceived - totalSpent) / totalReceived) * 100
+	}
+
+	return &models.BudgetOverview{
+		Version:        2,
+		PeriodStart:    startDate,
+		PeriodEnd:      endDate,
+		DaysElapsed:    daysElapsed,
+		DaysInPeriod:   daysInPeriod,
+		Thresholds:     models.DefaultStatusThresholds,
 		TotalBudget:    totalBudgetLimit,
-		SpentSoFar:     totalSpent,
+		Spent:          totalSpent,
 		Remaining:      totalBudgetLimit - totalSpent,
 		PercentageUsed: math.Round(overallPct),
+		Status:         budgetStatus(overallPct),
+		ProjectedSpend: math.Round(projectedTotal*100) / 100,
+		AtRiskCount:    atRisk,
+		Categories:     categories,
+
+		ExpectedIncome:     totalExpected,
+		ReceivedIncome:     totalReceived,
+		PlannedSavingsRate: math.Round(plannedSavingsRate),
+		ActualSavingsRate:  math.Round(actualSavingsRate),
+		IncomeCategories:   incomeStatuses,
+	}, nil
+}
+
+// Status labels and Tailwind classes of the v1 response
+var (
+	legacyStatusLabels = map[string]string{
+		models.BudgetStatusOnTrack:  "ON TRACK",
+		models.BudgetStatusWarning:  "WARNING",
+		models.BudgetStatusCritical: "CRITICAL",
+	}
+	legacyStatusColors = map[string]string{
+		models.BudgetStatusOnTrack:  "text-green-600",
+		models.BudgetStatusWarning:  "text-orange-600",
+		models.BudgetStatusCritical: "text-red-600",
+	}
+)
+
+// legacyBudgetOverview renders the overview in the v1 shape with Tailwind class names
+func legacyBudgetOverview(o *models.BudgetOverview) models.BudgetOverviewResponse {
+	var categoryStatuses []models.CategoryStatus
+	for _, cat := range o.Categories {
+		// Generate UI colors based on category color
+		// Assuming cat.Color is like "orange", "blue", etc.
+		categoryStatuses = append(categoryStatuses, models.CategoryStatus{
+			ID:            cat.ID,
+			Name:          cat.Name,
+			Limit:         cat.Limit,
+			Spent:         cat.Spent,
+			Percentage:    cat.Percentage,
+			Status:        legacyStatusLabels[cat.Status],
+			StatusColor:   legacyStatusColors[cat.Status],
+			Icon:          cat.Icon,
+			IconBg:        fmt.Sprintf("bg-%s-100", cat.Color),
+			IconColor:     fmt.Sprintf("text-%s-600", cat.Color),
+			ProgressColor: fmt.Sprintf("bg-%s-500", cat.Color),
+			Forecast:      cat.Forecast,
+		})
+	}
+
+	return models.BudgetOverviewResponse{
+		TotalBudget:    o.TotalBudget,
+		SpentSoFar:     o.Spent,
+		Remaining:      o.Remaining,
+		PercentageUsed: o.PercentageUsed,
 		Categories:     categoryStatuses,
+		ProjectedSpend: o.ProjectedSpend,
+		AtRiskCount:    o.AtRiskCount,
+		DaysElapsed:    o.DaysElapsed,
+		DaysInPeriod:   o.DaysInPeriod,
+
+		ExpectedIncome:     o.ExpectedIncome,
+		ReceivedIncome:     o.ReceivedIncome,
+		PlannedSavingsRate: o.PlannedSavingsRate,
+		ActualSavingsRate:  o.ActualSavingsRate,
+		IncomeCategories:   o.IncomeCategories,
 	}
+}
 
-	c.JSON(http.StatusOK, response)
+// budgetPeriod returns the [start, end) range of the monthly budget period containing t
+func budgetPeriod(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
 }
 
 // CreateBudgetCategory adds a new budget category
@@ -160,6 +310,15 @@ func CreateBudgetCategory(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if !input.ValidKind() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kind must be expense or income"})
+		return
+	}
+	if !input.ValidThresholds() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Thresholds must be between 0 and 1000"})
+		return
+	}
+
 	input.ID = primitive.NewObjectID()
 	input.UserID = userObjectID
 	input.CreatedAt = time.Now()
@@ -190,6 +349,15 @@ func UpdateBudgetCategory(c *gin.Context) {
 		return
 	}
 
+	if !input.ValidKind() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kind must be expense or income"})
+		return
+	}
+	if !input.ValidThresholds() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Thresholds must be between 0 and 1000"})
+		return
+	}
+
 	input.UpdatedAt = time.Now()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -198,9 +366,11 @@ func UpdateBudgetCategory(c *gin.Context) {
 	update := bson.M{
 		"$set": bson.M{
 			"name":       input.Name,
+			"kind":       input.Kind,
 			"limit":      input.Limit,
 			"icon":       input.Icon,
 			"color":      input.Color,
+			"thresholds": input.Thresholds,
 			"updated_at": input.UpdatedAt,
 		},
 	}
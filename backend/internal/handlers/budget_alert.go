@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+	"fintrack-backend/internal/notify"
+)
+
+// checkBudgetAlerts evaluates the thresholds of the budget for category in the
+// period containing date, and records + delivers any newly crossed threshold.
+// It is meant to run in the background after a transaction is saved.
+func checkBudgetAlerts(userID primitive.ObjectID, category string, date time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var budget models.BudgetCategory
+	err := db.Client.Database("fintrack").Collection("budgets").FindOne(ctx, bson.M{
+		"user_id": userID,
+		"name":    category,
+		"kind":    bson.M{"$ne": models.BudgetKindIncome},
+	}).Decode(&budget)
+	if err != nil {
+		// No budget for this category, nothing to evaluate
+		return
+	}
+	if budget.Limit <= 0 {
+		return
+	}
+
+	startDate, endDate := budgetPeriod(date)
+	spent, err := categorySpending(ctx, userID, category, startDate, endDate)
+	if err != nil {
+		log.Printf("Budget alert: failed to sum spending for %s: %v", category, err)
+		return
+	}
+
+	pct := (spent / budget.Limit) * 100
+	period := startDate.Format("2006-01")
+
+	for _, threshold := range budget.AlertThresholds() {
+		if pct < threshold {
+			continue
+		}
+
+		alert := models.BudgetAlert{
+			ID:         primitive.NewObjectID(),
+			UserID:     userID,
+			BudgetID:   budget.ID,
+			Category:   budget.Name,
+			Threshold:  threshold,
+			Period:     period,
+			Spent:      spent,
+			Limit:      budget.Limit,
+			Percentage: math.Round(pct),
+			CreatedAt:  time.Now(),
+		}
+
+		// $setOnInsert + upsert so that an already recorded alert is left untouched
+		filter := bson.M{"user_id": userID, "budget_id": budget.ID, "threshold": threshold, "period": period}
+		result, err := db.Client.Database("fintrack").Collection("budget_alerts").UpdateOne(ctx, filter,
+			bson.M{"$setOnInsert": alert}, options.Update().SetUpsert(true))
+		if err != nil {
+			if !mongo.IsDuplicateKeyError(err) {
+				log.Printf("Budget alert: failed to record alert for %s: %v", category, err)
+			}
+			continue
+		}
+		if result.UpsertedCount == 0 {
+			continue
+		}
+
+		notify.DispatchAsync(budgetAlertMessage(alert))
+	}
+}
+
+// categorySpending sums the absolute expense amount for category in [start, end)
+func categorySpending(ctx context.Context, userID primitive.ObjectID, category string, start, end time.Time) (float64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "user_id", Value: userID},
+			{Key: "category", Value: category},
+			{Key: "type", Value: "expense"},
+			{Key: "date", Value: bson.D{{Key: "$gte", Value: start}, {Key: "$lt", Value: end}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "spent", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$abs", Value: "$amount"}}}}},
+		}}},
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("transactions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+
+	var result []struct {
+		Spent float64 `bson:"spent"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return result[0].Spent, nil
+}
+
+func budgetAlertMessage(alert models.BudgetAlert) notify.Message {
+	title := fmt.Sprintf("%s budget at %.0f%%", alert.Category, alert.Percentage)
+	if alert.Threshold >= 100 {
+		title = fmt.Sprintf("%s budget exceeded", alert.Category)
+	}
+
+	return notify.Message{
+		UserID: alert.UserID,
+		Type:   "budget_alert",
+		Title:  title,
+		Body: fmt.Sprintf("You have spent %.2f of your %.2f %s budget for %s (%.0f%%), crossing the %.0f%% threshold.",
+			alert.Spent, alert.Limit, alert.Category, alert.Period, alert.Percentage, alert.Threshold),
+		Data: map[string]interface{}{
+			"alert_id":   alert.ID.Hex(),
+			"budget_id":  alert.BudgetID.Hex(),
+			"category":   alert.Category,
+			"threshold":  alert.Threshold,
+			"period":     alert.Period,
+			"spent":      alert.Spent,
+			"limit":      alert.Limit,
+			"percentage": alert.Percentage,
+		},
+	}
+}
+
+// GetBudgetAlerts lists recorded budget alerts, optionally filtered by ?period=YYYY-MM
+func GetBudgetAlerts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	filter := bson.M{"user_id": userObjectID}
+	if period := c.Query("period"); period != "" {
+		filter["period"] = period
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := db.Client.Database("fintrack").Collection("budget_alerts").Find(ctx, filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alerts"})
+		return
+	}
+
+	alerts := []models.BudgetAlert{}
+	if err = cursor.All(ctx, &alerts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
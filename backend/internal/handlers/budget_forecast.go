@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+const (
+	// Number of previous periods used for same-period spending patterns
+	forecastHistoryMonths = 3
+	// Historical shares below this are too noisy to extrapolate from
+	minHistoricalShare = 0.05
+)
+
+// periodProgress returns how many days of the period have started and the period length in days
+func periodProgress(now, start, end time.Time) (int, int) {
+	daysInPeriod := int(math.Round(end.Sub(start).Hours() / 24))
+	daysElapsed := int(math.Ceil(now.Sub(start).Hours() / 24))
+	if daysElapsed < 1 {
+		daysElapsed = 1
+	}
+	if daysElapsed > daysInPeriod {
+		daysElapsed = daysInPeriod
+	}
+	return daysElapsed, daysInPeriod
+}
+
+// historicalSpendShares returns, per category, the average share of a month's expenses
+// that had been spent by the same point (elapsed fraction) in the previous months.
+func historicalSpendShares(ctx context.Context, userID primitive.ObjectID, periodStart time.Time, elapsed float64) (map[string]float64, error) {
+	historyStart := periodStart.AddDate(0, -forecastHistoryMonths, 0)
+
+	cursor, err := db.Client.Database("fintrack").Collection("transactions").Find(ctx, bson.M{
+		"user_id": userID,
+		"type":    "expense",
+		"date":    bson.M{"$gte": historyStart, "$lt": periodStart},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []models.Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	type monthSpend struct{ total, byCutoff float64 }
+	months := make(map[string]map[string]*monthSpend) // category -> period -> spend
+
+	for _, t := range transactions {
+		start, end := budgetPeriod(t.Date.In(periodStart.Location()))
+		cutoff := start.Add(time.Duration(elapsed * float64(end.Sub(start))))
+		key := start.Format("2006-01")
+
+		if months[t.Category] == nil {
+			months[t.Category] = make(map[string]*monthSpend)
+		}
+		m := months[t.Category][key]
+		if m == nil {
+			m = &monthSpend{}
+			months[t.Category][key] = m
+		}
+
+		amt := math.Abs(t.Amount)
+		m.total += amt
+		if t.Date.Before(cutoff) {
+			m.byCutoff += amt
+		}
+	}
+
+	shares := make(map[string]float64)
+	for category, periods := range months {
+		sum, n := 0.0, 0
+		for _, m := range periods {
+			if m.total > 0 {
+				sum += m.byCutoff / m.total
+				n++
+			}
+		}
+		if n > 0 {
+			shares[category] = sum / float64(n)
+		}
+	}
+	return shares, nil
+}
+
+// recurringSpend is a category's recurring expenses in the current period
+type recurringSpend struct {
+	Matched  float64 // Charges already booked, found among the period's transactions
+	Period   float64 // Every occurrence due this period, booked or not
+	Upcoming float64 // Occurrences still due after now
+}
+
+// recurringExpenses sums expense schedule occurrences per category for the period
+// [start, end). Occurrences before now are matched against the period's expense
+// transactions by category and amount, each transaction matching at most once.
+func recurringExpenses(schedules []models.RecurringSchedule, transactions []models.Transaction, start, now, end time.Time) map[string]recurringSpend {
+	used := make(map[int]bool)
+	spend := make(map[string]recurringSpend)
+	for _, s := range schedules {
+		amount := math.Abs(s.Amount)
+		r := spend[s.Category]
+		r.Period += amount * float64(len(s.OccurrencesBetween(start, end)))
+		r.Upcoming += amount * float64(len(s.OccurrencesBetween(now, end)))
+
+		for range s.OccurrencesBetween(start, now) {
+			for i, t := range transactions {
+				if !used[i] && t.Type == "expense" && t.Category == s.Category && math.Abs(math.Abs(t.Amount)-amount) < 0.005 {
+					used[i] = true
+					r.Matched += math.Abs(t.Amount)
+					break
+				}
+			}
+		}
+		spend[s.Category] = r
+	}
+	return spend
+}
+
+// forecastCategory projects end-of-period spend. Recurring charges already booked
+// are taken out of the spend so far, which is then extrapolated by linear pace and,
+// when available, by the historical same-point share; every recurring charge of the
+// period is added back once.
+func forecastCategory(spent, limit, elapsed, historicalShare float64, recurring recurringSpend) models.CategoryForecast {
+	variableSpent := math.Max(spent-recurring.Matched, 0)
+
+	pace := variableSpent
+	if elapsed > 0 {
+		pace = variableSpent / elapsed
+	}
+
+	variable := pace
+	if historicalShare >= minHistoricalShare {
+		variable = (pace + variableSpent/historicalShare) / 2
+	} else {
+		historicalShare = 0
+	}
+	variable = math.Max(variable, variableSpent)
+
+	projected := variable + recurring.Period
+
+	projectedPct := 0.0
+	if limit > 0 {
+		projectedPct = (projected / limit) * 100
+	}
+
+	return models.CategoryForecast{
+		ProjectedSpend:      math.Round(projected*100) / 100,
+		ProjectedPercentage: math.Round(projectedPct),
+		ProjectedOverLimit:  limit > 0 && projected > limit,
+		PaceProjection:      math.Round((pace+recurring.Period)*100) / 100,
+		HistoricalShare:     math.Round(historicalShare*100) / 100,
+		UpcomingRecurring:   recurring.Upcoming,
+	}
+}
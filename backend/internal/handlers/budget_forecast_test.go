@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"fintrack-backend/internal/models"
+)
+
+func TestRecurringExpensesAndForecast(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	now := start.AddDate(0, 0, 3) // Day 3, about a tenth of the month
+
+	schedules := []models.RecurringSchedule{
+		{Category: "Housing", Amount: -1500, Type: "expense", Frequency: "monthly", Interval: 1, NextDate: start.AddDate(0, 0, 2)},
+		{Category: "Streaming", Amount: -15, Type: "expense", Frequency: "monthly", Interval: 1, NextDate: start.AddDate(0, 0, 20)},
+	}
+	transactions := []models.Transaction{
+		{Category: "Housing", Amount: -1500, Type: "expense", Date: start.AddDate(0, 0, 2)},
+		{Category: "Housing", Amount: -40, Type: "expense", Date: start.AddDate(0, 0, 1)},
+	}
+
+	recurring := recurringExpenses(schedules, transactions, start, now, end)
+	if got := recurring["Housing"]; got.Matched != 1500 || got.Period != 1500 || got.Upcoming != 0 {
+		t.Fatalf("Housing recurring = %+v", got)
+	}
+	if got := recurring["Streaming"]; got.Matched != 0 || got.Period != 15 || got.Upcoming != 15 {
+		t.Fatalf("Streaming recurring = %+v", got)
+	}
+
+	// Rent paid on day 3 must not be extrapolated over the month
+	daysElapsed, daysInPeriod := periodProgress(now, start, end)
+	elapsed := float64(daysElapsed) / float64(daysInPeriod)
+	forecast := forecastCategory(1540, 2000, elapsed, 0, recurring["Housing"])
+	if forecast.ProjectedOverLimit || forecast.ProjectedSpend > 2000 {
+		t.Errorf("Housing forecast = %+v, want within the 2000 limit", forecast)
+	}
+	if want := 1500 + 40/elapsed; forecast.ProjectedSpend < want-0.01 || forecast.ProjectedSpend > want+0.01 {
+		t.Errorf("Housing projected = %v, want %v", forecast.ProjectedSpend, want)
+	}
+
+	// Charges still due are added once
+	forecast = forecastCategory(0, 100, elapsed, 0, recurring["Streaming"])
+	if forecast.ProjectedSpend != 15 || forecast.UpcomingRecurring != 15 {
+		t.Errorf("Streaming forecast = %+v", forecast)
+	}
+}
@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+// Number of full months used for income scaling and spending suggestions
+const budgetLookbackMonths = 3
+
+// upsertBudgetCategories creates or updates budget categories by name for the user.
+// Icon, color, kind and thresholds are only changed when given.
+func upsertBudgetCategories(ctx context.Context, userID primitive.ObjectID, categories []models.BudgetCategory) (int, int, error) {
+	collection := db.Client.Database("fintrack").Collection("budgets")
+	created, updated := 0, 0
+
+	for _, b := range categories {
+		now := time.Now()
+		set := bson.M{"limit": b.Limit, "updated_at": now}
+		if b.Icon != "" {
+			set["icon"] = b.Icon
+		}
+		if b.Color != "" {
+			set["color"] = b.Color
+		}
+		if b.Kind != "" {
+			set["kind"] = b.Kind
+		}
+		if len(b.Thresholds) > 0 {
+			set["thresholds"] = b.Thresholds
+		}
+
+		update := bson.M{
+			"$set": set,
+			"$setOnInsert": bson.M{
+				"_id":        primitive.NewObjectID(),
+				"created_at": now,
+			},
+		}
+
+		result, err := collection.UpdateOne(ctx, bson.M{"user_id": userID, "name": b.Name}, update, options.Update().SetUpsert(true))
+		if err != nil {
+			return created, updated, err
+		}
+		if result.UpsertedCount > 0 {
+			created++
+		} else {
+			updated++
+		}
+	}
+
+	return created, updated, nil
+}
+
+// averageMonthlyTotals returns the average monthly income and the average monthly
+// expense per category over the last `months` full months
+func averageMonthlyTotals(ctx context.Context, userID primitive.ObjectID, months int) (float64, map[string]float64, error) {
+	currentStart, _ := budgetPeriod(time.Now())
+	from := currentStart.AddDate(0, -months, 0)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "user_id", Value: userID},
+			// Transfers between accounts are neither income nor expense
+			{Key: "type", Value: bson.D{{Key: "$in", Value: bson.A{"income", "expense"}}}},
+			{Key: "date", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: currentStart}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "type", Value: "$type"}, {Key: "category", Value: "$category"}}},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$abs", Value: "$amount"}}}}},
+		}}},
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("transactions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var rows []struct {
+		ID struct {
+			Type     string `bson:"type"`
+			Category string `bson:"category"`
+		} `bson:"_id"`
+		Total float64 `bson:"total"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return 0, nil, err
+	}
+
+	income := 0.0
+	expenses := make(map[string]float64)
+	for _, row := range rows {
+		if row.ID.Type == "income" {
+			income += row.Total
+		} else {
+			expenses[row.ID.Category] += row.Total / float64(months)
+		}
+	}
+
+	return income / float64(months), expenses, nil
+}
+
+// findBudgetTemplate resolves a built-in key or a saved template ID owned by the user
+func findBudgetTemplate(ctx context.Context, userID primitive.ObjectID, idParam string) (*models.BudgetTemplate, error) {
+	for _, t := range models.BuiltinBudgetTemplates {
+		if t.Key == idParam {
+			return &t, nil
+		}
+	}
+
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	var template models.BudgetTemplate
+	err = db.Client.Database("fintrack").Collection("budget_templates").FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&template)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetBudgetTemplates lists the built-in templates followed by the user's saved ones
+func GetBudgetTemplates(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	cursor, err := db.Client.Database("fintrack").Collection("budget_templates").Find(ctx, bson.M{"user_id": userObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch templates"})
+		return
+	}
+
+	var saved []models.BudgetTemplate
+	if err = cursor.All(ctx, &saved); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse templates"})
+		return
+	}
+
+	templates := append([]models.BudgetTemplate{}, models.BuiltinBudgetTemplates...)
+	templates = append(templates, saved...)
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreateBudgetTemplate saves a custom template. With `from_current` and `income`
+// set, the items are derived from the user's current budget categories.
+func CreateBudgetTemplate(c *gin.Context) {
+	var input struct {
+		Name        string                      `json:"name" binding:"required"`
+		Description string                      `json:"description"`
+		Items       []models.BudgetTemplateItem `json:"items"`
+		FromCurrent bool                        `json:"from_current"`
+		Income      float64                     `json:"income"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	items := input.Items
+	if input.FromCurrent {
+		if input.Income <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "income is required to save current budgets as a template"})
+			return
+		}
+
+		var budgets []models.BudgetCategory
+		cursor, err := db.Client.Database("fintrack").Collection("budgets").Find(ctx, bson.M{"user_id": userObjectID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budgets"})
+			return
+		}
+		if err = cursor.All(ctx, &budgets); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse budgets"})
+			return
+		}
+
+		items = nil
+		for _, b := range budgets {
+			// Templates split income into spending budgets; expected income is not one
+			if b.IsIncome() {
+				continue
+			}
+			items = append(items, models.BudgetTemplateItem{
+				Name:  b.Name,
+				Share: math.Round(b.Limit/input.Income*10000) / 10000,
+				Icon:  b.Icon,
+				Color: b.Color,
+			})
+		}
+	}
+
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Template must contain at least one category"})
+		return
+	}
+
+	totalShare := 0.0
+	for _, item := range items {
+		if item.Name == "" || item.Share <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Each item needs a name and a positive share"})
+			return
+		}
+		totalShare += item.Share
+	}
+	if totalShare > 1.0001 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Template shares add up to more than 100% of income"})
+		return
+	}
+
+	template := models.BudgetTemplate{
+		ID:          primitive.NewObjectID(),
+		UserID:      userObjectID,
+		Name:        input.Name,
+		Description: input.Description,
+		Items:       items,
+		CreatedAt:   time.Now(),
+	}
+
+	_, err := db.Client.Database("fintrack").Collection("budget_templates").InsertOne(ctx, template)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// DeleteBudgetTemplate removes a saved template
+func DeleteBudgetTemplate(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	result, err := db.Client.Database("fintrack").Collection("budget_templates").DeleteOne(ctx, bson.M{"_id": id, "user_id": userObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
+		return
+	}
+
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+}
+
+// ApplyBudgetTemplate creates or updates budget categories from a template scaled
+// to `income`, or to the average monthly income of the last months if omitted
+func ApplyBudgetTemplate(c *gin.Context) {
+	var input struct {
+		Income float64 `json:"income"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	template, err := findBudgetTemplate(ctx, userObjectID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	income := input.Income
+	if income <= 0 {
+		income, _, err = averageMonthlyTotals(ctx, userObjectID, budgetLookbackMonths)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate income"})
+			return
+		}
+		if income <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No income found in recent months, please provide income"})
+			return
+		}
+	}
+
+	var categories []models.BudgetCategory
+	for _, item := range template.Items {
+		categories = append(categories, models.BudgetCategory{
+			Name:  item.Name,
+			Limit: math.Round(item.Share*income*100) / 100,
+			Icon:  item.Icon,
+			Color: item.Color,
+		})
+	}
+
+	created, updated, err := upsertBudgetCategories(ctx, userObjectID, categories)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Template applied successfully",
+		"income":     income,
+		"created":    created,
+		"updated":    updated,
+		"categories": categories,
+	})
+}
+
+// BulkUpsertBudgetCategories creates or updates several budget categories by name
+func BulkUpsertBudgetCategories(c *gin.Context) {
+	var input struct {
+		Categories []models.BudgetCategory `json:"categories" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, b := range input.Categories {
+		if b.Name == "" || b.Limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Each category needs a name and a non-negative limit"})
+			return
+		}
+		if !b.ValidKind() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kind must be expense or income"})
+			return
+		}
+		if !b.ValidThresholds() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Thresholds must be between 0 and 1000"})
+			return
+		}
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, updated, err := upsertBudgetCategories(ctx, userObjectID, input.Categories)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save budget categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budgets saved successfully", "created": created, "updated": updated})
+}
+
+// SuggestBudget proposes category limits from the average spending of the last
+// months (?months=N, default 3), rounded up to the nearest 10
+func SuggestBudget(c *gin.Context) {
+	months := budgetLookbackMonths
+	if m, err := strconv.Atoi(c.Query("months")); err == nil && m > 0 && m <= 12 {
+		months = m
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	income, expenses, err := averageMonthlyTotals(ctx, userObjectID, months)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to analyse spending"})
+		return
+	}
+
+	suggestions := []models.SuggestedBudget{}
+	total := 0.0
+	for name, avg := range expenses {
+		limit := math.Ceil(avg/10) * 10
+		total += limit
+		suggestions = append(suggestions, models.SuggestedBudget{
+			Name:           name,
+			AverageMonthly: math.Round(avg*100) / 100,
+			Limit:          limit,
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].Limit > suggestions[j].Limit })
+
+	c.JSON(http.StatusOK, gin.H{
+		"months":         months,
+		"averageIncome":  math.Round(income*100) / 100,
+		"totalSuggested": total,
+		"categories":     suggestions,
+	})
+}
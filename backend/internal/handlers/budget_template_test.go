@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Every invalid item is rejected before anything is written
+func TestBulkUpsertBudgetCategoriesValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := map[string]string{
+		"missing name":       `{"categories":[{"limit":100}]}`,
+		"negative limit":     `{"categories":[{"name":"Food","limit":-1}]}`,
+		"unknown kind":       `{"categories":[{"name":"Food","limit":100,"kind":"foo"}]}`,
+		"negative level":     `{"categories":[{"name":"Food","limit":100,"thresholds":[-5]}]}`,
+		"level too high":     `{"categories":[{"name":"Food","limit":100,"thresholds":[50,1001]}]}`,
+		"one bad of two":     `{"categories":[{"name":"Food","limit":100},{"name":"Rent","limit":900,"kind":"savings"}]}`,
+		"missing categories": `{}`,
+	}
+	for name, body := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/budgets/bulk", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		BulkUpsertBudgetCategories(c)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, w.Code)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Dashboard series granularities
+const (
+	granularityDay   = "day"
+	granularityWeek  = "week"
+	granularityMonth = "month"
+)
+
+// dashboardRange is the reporting window of the dashboard, resolved in the user's time zone
+type dashboardRange struct {
+	From        time.Time
+	To          time.Time // exclusive
+	Granularity string
+	Location    *time.Location
+	Explicit    bool // from/to were given by the client
+}
+
+// parseDashboardRange reads ?from=, ?to= and ?granularity=. Dates are either
+// YYYY-MM-DD (a calendar day in loc, with "to" inclusive) or RFC 3339 timestamps.
+// Without from/to the window starts on the 1st of the month 6 months back in loc,
+// so the first monthly bucket is complete.
+func parseDashboardRange(from, to, granularity string, loc *time.Location, now time.Time) (dashboardRange, error) {
+	local := now.In(loc)
+	r := dashboardRange{
+		From:        time.Date(local.Year(), local.Month()-6, 1, 0, 0, 0, 0, loc),
+		To:          now,
+		Granularity: granularityMonth,
+		Location:    loc,
+	}
+
+	if from != "" {
+		t, err := parseDashboardDate(from, loc, false)
+		if err != nil {
+			return r, fmt.Errorf("invalid from date: %s", from)
+		}
+		r.From = t
+		r.Explicit = true
+	}
+	if to != "" {
+		t, err := parseDashboardDate(to, loc, true)
+		if err != nil {
+			return r, fmt.Errorf("invalid to date: %s", to)
+		}
+		r.To = t
+		r.Explicit = true
+	}
+	if !r.From.Before(r.To) {
+		return r, fmt.Errorf("from must be before to")
+	}
+
+	switch granularity {
+	case "":
+	case granularityDay, granularityWeek, granularityMonth:
+		r.Granularity = granularity
+	default:
+		return r, fmt.Errorf("granularity must be day, week or month")
+	}
+
+	return r, nil
+}
+
+func parseDashboardDate(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// dailyFrom is where the daily stats start: the range start when one was given,
+// otherwise local midnight 7 days before now so the first day is complete
+func (r dashboardRange) dailyFrom(now time.Time) time.Time {
+	if r.Explicit {
+		return r.From
+	}
+	local := now.In(r.Location)
+	return time.Date(local.Year(), local.Month(), local.Day()-7, 0, 0, 0, 0, r.Location)
+}
+
+// dateMatch is the $match condition on "date" for the range. The default window
+// is open-ended so that future-dated transactions keep showing up as before.
+func (r dashboardRange) dateMatch(from time.Time) bson.D {
+	cond := bson.D{{Key: "$gte", Value: from}}
+	if r.Explicit {
+		cond = append(cond, bson.E{Key: "$lt", Value: r.To})
+	}
+	return cond
+}
+
+// inRange is an aggregation expression that is true when "$date" falls in the
+// range. Without from/to the stats have always covered all time, so every date is in it.
+func (r dashboardRange) inRange() interface{} {
+	if !r.Explicit {
+		return true
+	}
+	return bson.D{{Key: "$and", Value: bson.A{
+		bson.D{{Key: "$gte", Value: bson.A{"$date", r.From}}},
+		bson.D{{Key: "$lt", Value: bson.A{"$date", r.To}}},
+	}}}
+}
+
+// dateGroupKey builds the $group _id that buckets "$date" by granularity in the
+// range's time zone. Day and month buckets keep the {year, month, day} shape the
+// dashboard has always returned; weeks are ISO weeks.
+func (r dashboardRange) dateGroupKey(granularity string) bson.D {
+	part := func(op string) bson.D {
+		return bson.D{{Key: op, Value: bson.D{
+			{Key: "date", Value: "$date"},
+			{Key: "timezone", Value: r.Location.String()},
+		}}}
+	}
+
+	switch granularity {
+	case granularityDay:
+		return bson.D{
+			{Key: "year", Value: part("$year")},
+			{Key: "month", Value: part("$month")},
+			{Key: "day", Value: part("$dayOfMonth")},
+		}
+	case granularityWeek:
+		return bson.D{
+			{Key: "year", Value: part("$isoWeekYear")},
+			{Key: "week", Value: part("$isoWeek")},
+		}
+	default:
+		return bson.D{
+			{Key: "year", Value: part("$year")},
+			{Key: "month", Value: part("$month")},
+		}
+	}
+}
+
+// dateGroupSort orders buckets produced by dateGroupKey chronologically
+func dateGroupSort(granularity string) bson.D {
+	switch granularity {
+	case granularityDay:
+		return bson.D{{Key: "_id.year", Value: 1}, {Key: "_id.month", Value: 1}, {Key: "_id.day", Value: 1}}
+	case granularityWeek:
+		return bson.D{{Key: "_id.year", Value: 1}, {Key: "_id.week", Value: 1}}
+	default:
+		return bson.D{{Key: "_id.year", Value: 1}, {Key: "_id.month", Value: 1}}
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseDashboardRange(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	r, err := parseDashboardRange("2024-03-01", "2024-03-31", "week", loc, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Explicit || r.Granularity != granularityWeek {
+		t.Errorf("range = %+v", r)
+	}
+	// "to" is inclusive, so the range ends at midnight after it in the user's zone
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, loc); !r.From.Equal(want) {
+		t.Errorf("From = %v, want %v", r.From, want)
+	}
+	if want := time.Date(2024, 4, 1, 0, 0, 0, 0, loc); !r.To.Equal(want) {
+		t.Errorf("To = %v, want %v", r.To, want)
+	}
+
+	r, err = parseDashboardRange("", "", "", loc, now)
+	if err != nil || r.Explicit || r.Granularity != granularityMonth {
+		t.Errorf("default range = %+v, %v", r, err)
+	}
+	// The default window starts at local midnight on the 1st, 6 months back
+	if want := time.Date(2023, 12, 1, 0, 0, 0, 0, loc); !r.From.Equal(want) {
+		t.Errorf("default From = %v, want %v", r.From, want)
+	}
+	if want := time.Date(2024, 6, 8, 0, 0, 0, 0, loc); !r.dailyFrom(now).Equal(want) {
+		t.Errorf("default dailyFrom = %v, want %v", r.dailyFrom(now), want)
+	}
+
+	// Late on the 31st in UTC is already the next month and day in Berlin
+	late := time.Date(2024, 7, 31, 23, 30, 0, 0, time.UTC)
+	r, _ = parseDashboardRange("", "", "", loc, late)
+	if want := time.Date(2024, 2, 1, 0, 0, 0, 0, loc); !r.From.Equal(want) {
+		t.Errorf("default From at %v = %v, want %v", late, r.From, want)
+	}
+	if want := time.Date(2024, 7, 25, 0, 0, 0, 0, loc); !r.dailyFrom(late).Equal(want) {
+		t.Errorf("default dailyFrom at %v = %v, want %v", late, r.dailyFrom(late), want)
+	}
+
+	r, _ = parseDashboardRange("2024-03-01", "", "", loc, now)
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, loc); !r.dailyFrom(now).Equal(want) {
+		t.Errorf("explicit dailyFrom = %v, want %v", r.dailyFrom(now), want)
+	}
+
+	for _, tt := range [][3]string{
+		{"2024-03-31", "2024-03-01", ""},
+		{"yesterday", "", ""},
+		{"", "2024-13-01", ""},
+		{"", "", "year"},
+	} {
+		if _, err := parseDashboardRange(tt[0], tt[1], tt[2], loc, now); err == nil {
+			t.Errorf("parseDashboardRange(%q, %q, %q) accepted", tt[0], tt[1], tt[2])
+		}
+	}
+}
+
+func TestDashboardRangeInRange(t *testing.T) {
+	if got := (dashboardRange{}).inRange(); got != true {
+		t.Errorf("default inRange = %v, want true", got)
+	}
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	got, ok := (dashboardRange{From: from, To: to, Explicit: true}).inRange().(bson.D)
+	if !ok || len(got) != 1 || got[0].Key != "$and" {
+		t.Fatalf("explicit inRange = %v", got)
+	}
+	bounds := got[0].Value.(bson.A)
+	if len(bounds) != 2 || bounds[0].(bson.D)[0].Key != "$gte" || bounds[1].(bson.D)[0].Key != "$lt" {
+		t.Errorf("explicit inRange bounds = %v", bounds)
+	}
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/email"
+	"fintrack-backend/internal/models"
+)
+
+// Number of categories listed in the weekly digest
+const digestTopCategories = 5
+
+// GetEmailPreferences returns which email lists the user is subscribed to
+func GetEmailPreferences(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	c.JSON(http.StatusOK, email.LoadPreferences(ctx, userObjectID))
+}
+
+// UpdateEmailPreferences saves the user's email list subscriptions
+func UpdateEmailPreferences(c *gin.Context) {
+	var input models.EmailPreferences
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	input.UserID = userObjectID
+	input.UpdatedAt = time.Now()
+
+	_, err := db.Client.Database("fintrack").Collection("email_preferences").ReplaceOne(ctx,
+		bson.M{"user_id": userObjectID}, input, options.Replace().SetUpsert(true))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save email preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, input)
+}
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>Unsubscribe - FinTrack</title></head>
+<body style="font-family: Helvetica, Arial, sans-serif; color: #0f172a; max-width: 480px; margin: 64px auto; text-align: center;">
+<h1>FinTrack</h1>
+{{if .Error}}<p>{{.Error}}</p>
+{{else if .Done}}<p>You have been unsubscribed from <strong>{{.List}}</strong> emails.</p>
+<p><a href="{{.AppURL}}/settings/notifications">Manage email preferences</a></p>
+{{else}}<p>Stop receiving <strong>{{.List}}</strong> emails?</p>
+<form method="post"><button type="submit" style="padding: 10px 16px;">Unsubscribe</button></form>
+{{end}}</body>
+</html>
+`))
+
+var emailListLabels = map[string]string{
+	models.EmailListWeeklyDigest:      "weekly digest",
+	models.EmailListBudgetAlerts:      "budget alert",
+	models.EmailListMonthlyStatements: "monthly statement",
+}
+
+func renderUnsubscribePage(c *gin.Context, status int, list string, done bool, errMsg string) {
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	unsubscribePage.Execute(c.Writer, gin.H{
+		"List":   emailListLabels[list],
+		"Done":   done,
+		"Error":  errMsg,
+		"AppURL": email.AppURL(),
+	})
+}
+
+// UnsubscribeConfirm shows the page linked from emails. Unsubscribing only happens
+// on POST, so link scanners that prefetch the URL do not unsubscribe anyone.
+func UnsubscribeConfirm(c *gin.Context) {
+	_, list, err := email.ParseUnsubscribeToken(c.Query("token"))
+	if err != nil {
+		renderUnsubscribePage(c, http.StatusBadRequest, "", false, "This unsubscribe link is invalid.")
+		return
+	}
+	renderUnsubscribePage(c, http.StatusOK, list, false, "")
+}
+
+// Unsubscribe removes the user from the list named in ?token=. It serves both the
+// confirmation form and RFC 8058 one-click requests from mail clients.
+func Unsubscribe(c *gin.Context) {
+	userID, list, err := email.ParseUnsubscribeToken(c.Query("token"))
+	if err != nil {
+		renderUnsubscribePage(c, http.StatusBadRequest, "", false, "This unsubscribe link is invalid.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// List names double as preference fields. Users without saved preferences
+	// start from the defaults for the other lists.
+	defaults := models.DefaultEmailPreferences(userID)
+	onInsert := bson.M{
+		models.EmailListWeeklyDigest:      defaults.WeeklyDigest,
+		models.EmailListBudgetAlerts:      defaults.BudgetAlerts,
+		models.EmailListMonthlyStatements: defaults.MonthlyStatements,
+	}
+	delete(onInsert, list)
+
+	_, err = db.Client.Database("fintrack").Collection("email_preferences").UpdateOne(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": bson.M{list: false, "updated_at": time.Now()}, "$setOnInsert": onInsert},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		renderUnsubscribePage(c, http.StatusInternalServerError, "", false, "Something went wrong, please try again later.")
+		return
+	}
+
+	renderUnsubscribePage(c, http.StatusOK, list, true, "")
+}
+
+// buildWeeklyDigest summarizes the seven days before periodEnd, which must be in the
+// user's time zone
+func buildWeeklyDigest(ctx context.Context, userID primitive.ObjectID, periodEnd time.Time) (*models.WeeklyDigest, error) {
+	current := models.ReportPeriod{From: periodEnd.AddDate(0, 0, -7), To: periodEnd}
+	previous := models.ReportPeriod{From: periodEnd.AddDate(0, 0, -14), To: current.From}
+
+	income, expenses, err := categoryTotals(ctx, userID, current)
+	if err != nil {
+		return nil, err
+	}
+	_, previousExpenses, err := categoryTotals(ctx, userID, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &models.WeeklyDigest{
+		PeriodStart:   current.From,
+		PeriodEnd:     current.To.AddDate(0, 0, -1),
+		TopCategories: []models.StatementCategory{},
+		BudgetsAtRisk: []models.BudgetCategoryOverview{},
+	}
+	for _, v := range income {
+		d.Income += v
+	}
+	for _, v := range expenses {
+		d.Expenses += v
+	}
+	for _, v := range previousExpenses {
+		d.PreviousExpenses += v
+	}
+	for name, v := range expenses {
+		if v <= 0 {
+			continue
+		}
+		d.TopCategories = append(d.TopCategories, models.StatementCategory{
+			Name:   name,
+			Amount: math.Round(v*100) / 100,
+			Share:  math.Round(v / d.Expenses * 100),
+		})
+	}
+	sort.Slice(d.TopCategories, func(i, j int) bool { return d.TopCategories[i].Amount > d.TopCategories[j].Amount })
+	if len(d.TopCategories) > digestTopCategories {
+		d.TopCategories = d.TopCategories[:digestTopCategories]
+	}
+
+	if d.PreviousExpenses > 0 {
+		d.ExpenseChange = math.Round((d.Expenses - d.PreviousExpenses) / d.PreviousExpenses * 100)
+	}
+	d.Income = math.Round(d.Income*100) / 100
+	d.Expenses = math.Round(d.Expenses*100) / 100
+	d.PreviousExpenses = math.Round(d.PreviousExpenses*100) / 100
+	d.Net = math.Round((d.Income-d.Expenses)*100) / 100
+
+	// Budgets as they stood at the end of the week
+	overview, err := buildBudgetOverview(ctx, userID, periodEnd.Add(-time.Second))
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range overview.Categories {
+		if b.Status == models.BudgetStatusWarning || b.Status == models.BudgetStatusCritical {
+			d.BudgetsAtRisk = append(d.BudgetsAtRisk, b)
+		}
+	}
+
+	d.OpenAnomalies, err = db.Client.Database("fintrack").Collection("anomalies").CountDocuments(ctx,
+		bson.M{"user_id": userID, "status": models.AnomalyOpen})
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// SendWeeklyDigests mails last week's summary to every subscribed user. It is run
+// on Monday at noon UTC by the scheduler, when it is Monday almost everywhere.
+func SendWeeklyDigests(ctx context.Context) error {
+	cursor, err := db.GetCollection("users").Find(ctx, bson.M{"email": bson.M{"$ne": ""}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		if !email.LoadPreferences(ctx, user.ID).WeeklyDigest {
+			continue
+		}
+
+		// The week ends at the most recent Monday midnight in the user's time zone
+		now := time.Now().In(timezoneLocation(user.Timezone))
+		sinceMonday := (int(now.Weekday()) + 6) % 7
+		periodEnd := time.Date(now.Year(), now.Month(), now.Day()-sinceMonday, 0, 0, 0, 0, now.Location())
+		digest, err := buildWeeklyDigest(ctx, user.ID, periodEnd)
+		if err != nil {
+			log.Printf("Weekly digest for user %s failed: %v", user.ID.Hex(), err)
+			continue
+		}
+		// Nothing happened, nothing to report
+		if digest.Income == 0 && digest.Expenses == 0 && len(digest.BudgetsAtRisk) == 0 {
+			continue
+		}
+
+		if err := email.Send(ctx, user, models.EmailListWeeklyDigest, "weekly_digest", digest); err != nil {
+			log.Printf("Weekly digest for user %s could not be sent: %v", user.ID.Hex(), err)
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+var (
+	errInsufficientGoalBalance = errors.New("insufficient goal balance")
+	errGoalBalanceChanged      = errors.New("goal balance changed concurrently")
+	errGoalAccountBacked       = errors.New("goal balance is derived from linked accounts")
+	errGoalArchived            = errors.New("goal is archived")
+)
+
+// recordGoalContribution applies the contribution to the goal balance with an atomic
+// $inc and stores it in the ledger. When expectedBalance is non-nil the update only
+// applies if the goal still holds that balance (optimistic concurrency).
+// It returns the goal as it is after the update.
+func recordGoalContribution(ctx context.Context, contribution *models.GoalContribution, expectedBalance *float64) (*models.Goal, error) {
+	goals := db.Client.Database("fintrack").Collection("goals")
+
+	// Account-backed goals take their balance from the accounts, not the ledger
+	filter := bson.M{
+		"_id":        contribution.GoalID,
+		"user_id":    contribution.UserID,
+		"accounts.0": bson.M{"$exists": false},
+		"status":     bson.M{"$ne": models.GoalStatusArchived},
+	}
+	if expectedBalance != nil {
+		filter["current_amount"] = *expectedBalance
+	} else if contribution.Amount < 0 {
+		filter["current_amount"] = bson.M{"$gte": -contribution.Amount}
+	}
+
+	var goal models.Goal
+	err := goals.FindOneAndUpdate(ctx, filter,
+		bson.M{"$inc": bson.M{"current_amount": contribution.Amount}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&goal)
+	if err == mongo.ErrNoDocuments {
+		// Distinguish a missing goal from a failed balance condition
+		var existing models.Goal
+		findErr := goals.FindOne(ctx, bson.M{"_id": contribution.GoalID, "user_id": contribution.UserID}).Decode(&existing)
+		if findErr == nil {
+			if existing.Status == models.GoalStatusArchived {
+				return nil, errGoalArchived
+			}
+			if len(existing.Accounts) > 0 {
+				return nil, errGoalAccountBacked
+			}
+			if expectedBalance != nil {
+				return nil, errGoalBalanceChanged
+			}
+			return nil, errInsufficientGoalBalance
+		}
+		return nil, mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	contribution.ID = primitive.NewObjectID()
+	contribution.CreatedAt = time.Now()
+	if contribution.Date.IsZero() {
+		contribution.Date = contribution.CreatedAt
+	}
+
+	if _, err = db.Client.Database("fintrack").Collection("goal_contributions").InsertOne(ctx, contribution); err != nil {
+		// Keep the balance consistent with the ledger
+		if _, revertErr := goals.UpdateOne(ctx, bson.M{"_id": goal.ID}, bson.M{"$inc": bson.M{"current_amount": -contribution.Amount}}); revertErr != nil {
+			log.Printf("Goal %s: failed to revert balance after ledger error: %v", goal.ID.Hex(), revertErr)
+		}
+		return nil, err
+	}
+
+	checkGoalMilestones(ctx, goal)
+	return &goal, nil
+}
+
+// contributionErrorStatus maps recordGoalContribution errors to HTTP responses
+func contributionErrorStatus(err error) (int, string) {
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return http.StatusNotFound, "Goal not found"
+	case errors.Is(err, errInsufficientGoalBalance):
+		return http.StatusBadRequest, "Withdrawal exceeds the goal balance"
+	case errors.Is(err, errGoalArchived):
+		return http.StatusBadRequest, "This goal is archived; restore it before adding contributions"
+	case errors.Is(err, errGoalAccountBacked):
+		return http.StatusBadRequest, "This goal is backed by accounts; move money between accounts instead"
+	case errors.Is(err, errGoalBalanceChanged):
+		return http.StatusConflict, "Goal balance was changed by another request, please reload"
+	default:
+		return http.StatusInternalServerError, "Failed to record contribution"
+	}
+}
+
+// GetGoalContributions lists the contribution history of a goal, newest first
+func GetGoalContributions(c *gin.Context) {
+	idParam := c.Param("id")
+	goalID, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "date", Value: -1}, {Key: "created_at", Value: -1}})
+
+	cursor, err := db.Client.Database("fintrack").Collection("goal_contributions").Find(ctx,
+		bson.M{"goal_id": goalID, "user_id": userObjectID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contributions"})
+		return
+	}
+
+	contributions := []models.GoalContribution{}
+	if err = cursor.All(ctx, &contributions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse contributions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, contributions)
+}
+
+// CreateGoalContribution deposits into or withdraws from a goal
+func CreateGoalContribution(c *gin.Context) {
+	idParam := c.Param("id")
+	goalID, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		Type   string    `json:"type" binding:"required,oneof=deposit withdraw"`
+		Amount float64   `json:"amount" binding:"required,gt=0"`
+		Date   time.Time `json:"date"`
+		Note   string    `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	amount := input.Amount
+	if input.Type == models.ContributionWithdraw {
+		amount = -amount
+	}
+
+	contribution := models.GoalContribution{
+		UserID: userObjectID,
+		GoalID: goalID,
+		Type:   input.Type,
+		Amount: amount,
+		Date:   input.Date,
+		Note:   input.Note,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	goal, err := recordGoalContribution(ctx, &contribution, nil)
+	if err != nil {
+		status, msg := contributionErrorStatus(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"contribution": contribution, "goal": goal})
+}
+
+// contributionDeleteBlocked explains why the goal's ledger entries cannot be removed,
+// or returns "" when they can
+func contributionDeleteBlocked(goal models.Goal) string {
+	switch {
+	case goal.Status == models.GoalStatusArchived:
+		return "This goal is archived; restore it before changing contributions"
+	case len(goal.Accounts) > 0:
+		return "This goal is backed by accounts; its contributions cannot be changed"
+	default:
+		return ""
+	}
+}
+
+// DeleteGoalContribution removes a ledger entry and reverses its effect on the balance
+func DeleteGoalContribution(c *gin.Context) {
+	goalID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	contributionID, err := primitive.ObjectIDFromHex(c.Param("contributionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	// Like new contributions, removals only apply to goals whose balance is the ledger
+	var goal models.Goal
+	err = db.Client.Database("fintrack").Collection("goals").FindOne(ctx,
+		bson.M{"_id": goalID, "user_id": userObjectID}).Decode(&goal)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goal"})
+		return
+	}
+	if msg := contributionDeleteBlocked(goal); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// FindOneAndDelete so that concurrent deletes reverse the entry only once
+	contributions := db.Client.Database("fintrack").Collection("goal_contributions")
+	var contribution models.GoalContribution
+	err = contributions.FindOneAndDelete(ctx,
+		bson.M{"_id": contributionID, "goal_id": goalID, "user_id": userObjectID}).Decode(&contribution)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contribution not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contribution"})
+		return
+	}
+
+	// The conditions are repeated so a goal archived or linked to accounts in the
+	// meantime is not changed. Reversing a deposit that has since been withdrawn must
+	// not leave the balance negative either.
+	filter := bson.M{
+		"_id":        goalID,
+		"user_id":    userObjectID,
+		"accounts.0": bson.M{"$exists": false},
+		"status":     bson.M{"$ne": models.GoalStatusArchived},
+	}
+	if contribution.Amount > 0 {
+		filter["current_amount"] = bson.M{"$gte": contribution.Amount}
+	}
+	result, err := db.Client.Database("fintrack").Collection("goals").UpdateOne(ctx, filter,
+		bson.M{"$inc": bson.M{"current_amount": -contribution.Amount}})
+	if err != nil || result.MatchedCount == 0 {
+		// Put the entry back so the ledger keeps matching the balance
+		if _, restoreErr := contributions.InsertOne(ctx, contribution); restoreErr != nil {
+			log.Printf("Goal %s: failed to restore contribution %s: %v", goalID.Hex(), contribution.ID.Hex(), restoreErr)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update goal balance"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		msg := "Deleting this contribution would make the goal balance negative"
+		if err := db.Client.Database("fintrack").Collection("goals").FindOne(ctx, bson.M{"_id": goalID}).Decode(&goal); err == nil {
+			if blocked := contributionDeleteBlocked(goal); blocked != "" {
+				msg = blocked
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contribution deleted"})
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"fintrack-backend/internal/models"
+)
+
+func TestContributionDeleteBlocked(t *testing.T) {
+	tests := []struct {
+		name    string
+		goal    models.Goal
+		blocked bool
+	}{
+		{"active", models.Goal{Status: models.GoalStatusActive}, false},
+		{"paused", models.Goal{Status: models.GoalStatusPaused}, false},
+		{"achieved", models.Goal{Status: models.GoalStatusAchieved}, false},
+		{"archived", models.Goal{Status: models.GoalStatusArchived}, true},
+		{"account-backed", models.Goal{Status: models.GoalStatusActive, Accounts: []models.GoalAccountLink{{AccountID: primitive.NewObjectID()}}}, true},
+	}
+	for _, tt := range tests {
+		if got := contributionDeleteBlocked(tt.goal) != ""; got != tt.blocked {
+			t.Errorf("%s: blocked = %v, want %v", tt.name, got, tt.blocked)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+// goalAllocation is the share of a funding amount assigned to one goal
+type goalAllocation struct {
+	GoalID primitive.ObjectID `json:"goal_id"`
+	Amount float64            `json:"amount"`
+}
+
+// validateFundingRule fills defaults and checks a rule from user input
+func validateFundingRule(rule *models.GoalFundingRule) string {
+	switch rule.Trigger {
+	case models.FundingTriggerIncome:
+		if rule.Percentage <= 0 && rule.Amount <= 0 {
+			return "Income rules need a percentage or an amount"
+		}
+		if rule.Percentage > 100 {
+			return "Percentage must be at most 100"
+		}
+	case models.FundingTriggerSchedule:
+		if rule.Amount <= 0 {
+			return "Scheduled rules need a positive amount"
+		}
+		if rule.Frequency == "" {
+			rule.Frequency = "monthly"
+		}
+		if !validFrequencies[rule.Frequency] {
+			return "Frequency must be weekly, monthly or yearly"
+		}
+		if rule.Interval <= 0 {
+			rule.Interval = 1
+		}
+		if rule.NextDate.IsZero() {
+			return "next_date is required for scheduled rules"
+		}
+		rule.Percentage = 0
+	default:
+		return "Trigger must be income or schedule"
+	}
+	return ""
+}
+
+// allocateWaterfall fills goals in order up to their remaining target and returns
+// the allocations; account-backed and inactive (paused, achieved, archived) goals
+// are skipped and money left after all goals are full is not allocated
+func allocateWaterfall(amount float64, goals []models.Goal) []goalAllocation {
+	var allocations []goalAllocation
+	left := amount
+	for _, g := range goals {
+		if left <= 0 {
+			break
+		}
+		remaining := g.TargetAmount - g.CurrentAmount
+		if remaining <= 0 || len(g.Accounts) > 0 || g.State() != models.GoalStatusActive {
+			continue
+		}
+		share := math.Min(remaining, left)
+		share = math.Round(share*100) / 100
+		allocations = append(allocations, goalAllocation{GoalID: g.ID, Amount: share})
+		left -= share
+	}
+	return allocations
+}
+
+// goalsByPriority returns the user's goals ordered for waterfall allocation
+func goalsByPriority(ctx context.Context, userID primitive.ObjectID) ([]models.Goal, error) {
+	cursor, err := db.Client.Database("fintrack").Collection("goals").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	var goals []models.Goal
+	if err = cursor.All(ctx, &goals); err != nil {
+		return nil, err
+	}
+
+	sortGoalsByPriority(goals)
+	return goals, nil
+}
+
+// sortGoalsByPriority orders goals for waterfall allocation: priority 1 first,
+// unset (0) priorities last, ties by creation date
+func sortGoalsByPriority(goals []models.Goal) {
+	rank := func(g models.Goal) int {
+		if g.Priority <= 0 {
+			return math.MaxInt32
+		}
+		return g.Priority
+	}
+	sort.SliceStable(goals, func(i, j int) bool {
+		if rank(goals[i]) != rank(goals[j]) {
+			return rank(goals[i]) < rank(goals[j])
+		}
+		return goals[i].CreatedAt.Before(goals[j].CreatedAt)
+	})
+}
+
+// fundGoals allocates amount according to the rule and records the contributions
+func fundGoals(ctx context.Context, rule models.GoalFundingRule, amount float64, date time.Time, transactionID *primitive.ObjectID) ([]goalAllocation, error) {
+	var allocations []goalAllocation
+	if rule.GoalID != nil {
+		// Paused, achieved and archived goals stop receiving automatic funding
+		var goal models.Goal
+		if err := db.Client.Database("fintrack").Collection("goals").FindOne(ctx, bson.M{"_id": *rule.GoalID, "user_id": rule.UserID}).Decode(&goal); err != nil {
+			return nil, err
+		}
+		if goal.State() != models.GoalStatusActive {
+			return nil, nil
+		}
+		allocations = []goalAllocation{{GoalID: *rule.GoalID, Amount: math.Round(amount*100) / 100}}
+	} else {
+		goals, err := goalsByPriority(ctx, rule.UserID)
+		if err != nil {
+			return nil, err
+		}
+		allocations = allocateWaterfall(amount, goals)
+	}
+
+	ruleID := rule.ID
+	for _, a := range allocations {
+		if a.Amount <= 0 {
+			continue
+		}
+		_, err := recordGoalContribution(ctx, &models.GoalContribution{
+			UserID:        rule.UserID,
+			GoalID:        a.GoalID,
+			Type:          models.ContributionDeposit,
+			Amount:        a.Amount,
+			Date:          date,
+			Note:          "Funding rule: " + rule.Name,
+			RuleID:        &ruleID,
+			TransactionID: transactionID,
+		}, nil)
+		if err != nil {
+			return allocations, err
+		}
+	}
+	return allocations, nil
+}
+
+// fundingRuleMatches reports whether an income transaction qualifies for the rule
+func fundingRuleMatches(rule models.GoalFundingRule, t models.Transaction) bool {
+	if rule.MatchCategory != "" && !strings.EqualFold(rule.MatchCategory, t.Category) {
+		return false
+	}
+	if rule.MatchDescription != "" && !strings.Contains(strings.ToLower(t.Description), strings.ToLower(rule.MatchDescription)) {
+		return false
+	}
+	return true
+}
+
+// applyIncomeFundingRules creates goal contributions for every active income rule
+// matching a newly created income transaction. Meant to run in the background.
+func applyIncomeFundingRules(transaction models.Transaction) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := db.Client.Database("fintrack").Collection("goal_funding_rules").Find(ctx, bson.M{
+		"user_id": transaction.UserID,
+		"trigger": models.FundingTriggerIncome,
+		"active":  true,
+	})
+	if err != nil {
+		log.Printf("Funding rules: failed to load rules: %v", err)
+		return
+	}
+
+	var rules []models.GoalFundingRule
+	if err = cursor.All(ctx, &rules); err != nil {
+		log.Printf("Funding rules: failed to parse rules: %v", err)
+		return
+	}
+
+	income := math.Abs(transaction.Amount)
+	for _, rule := range rules {
+		if !fundingRuleMatches(rule, transaction) {
+			continue
+		}
+
+		amount := rule.Amount
+		if rule.Percentage > 0 {
+			amount = income * rule.Percentage / 100
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		transactionID := transaction.ID
+		if _, err := fundGoals(ctx, rule, amount, transaction.Date, &transactionID); err != nil {
+			log.Printf("Funding rules: rule %s failed for transaction %s: %v", rule.ID.Hex(), transaction.ID.Hex(), err)
+		}
+	}
+}
+
+// RunScheduledFundingRules executes scheduled funding rules that are due
+func RunScheduledFundingRules(ctx context.Context) error {
+	collection := db.Client.Database("fintrack").Collection("goal_funding_rules")
+	now := time.Now()
+
+	cursor, err := collection.Find(ctx, bson.M{
+		"trigger":   models.FundingTriggerSchedule,
+		"active":    true,
+		"next_date": bson.M{"$lte": now},
+	})
+	if err != nil {
+		return err
+	}
+
+	var rules []models.GoalFundingRule
+	if err = cursor.All(ctx, &rules); err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		// Claim the occurrence by moving next_date forward; only one runner wins
+		next := models.AdvanceDate(rule.NextDate, rule.Frequency, rule.Interval)
+		result, err := collection.UpdateOne(ctx,
+			bson.M{"_id": rule.ID, "next_date": rule.NextDate},
+			bson.M{"$set": bson.M{"next_date": next, "updated_at": now}})
+		if err != nil || result.ModifiedCount == 0 {
+			continue
+		}
+
+		if _, err := fundGoals(ctx, rule, rule.Amount, rule.NextDate, nil); err != nil {
+			log.Printf("Funding rules: scheduled rule %s failed: %v", rule.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// GetFundingRules lists the user's goal funding rules
+func GetFundingRules(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	cursor, err := db.Client.Database("fintrack").Collection("goal_funding_rules").Find(ctx, bson.M{"user_id": userObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch funding rules"})
+		return
+	}
+
+	rules := []models.GoalFundingRule{}
+	if err = cursor.All(ctx, &rules); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse funding rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateFundingRule adds a goal funding rule
+func CreateFundingRule(c *gin.Context) {
+	var rule models.GoalFundingRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if msg := validateFundingRule(&rule); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if rule.GoalID != nil {
+		count, err := db.Client.Database("fintrack").Collection("goals").CountDocuments(ctx, bson.M{"_id": *rule.GoalID, "user_id": userObjectID})
+		if err != nil || count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Goal not found"})
+			return
+		}
+	}
+
+	rule.ID = primitive.NewObjectID()
+	rule.UserID = userObjectID
+	rule.Active = true
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = time.Now()
+
+	_, err := db.Client.Database("fintrack").Collection("goal_funding_rules").InsertOne(ctx, rule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create funding rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateFundingRule replaces the editable fields of a funding rule
+func UpdateFundingRule(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	// Active is a pointer so that omitting it leaves the rule's state unchanged
+	var input struct {
+		models.GoalFundingRule
+		Active *bool `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if msg := validateFundingRule(&input.GoalFundingRule); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if input.GoalID != nil {
+		count, err := db.Client.Database("fintrack").Collection("goals").CountDocuments(ctx, bson.M{"_id": *input.GoalID, "user_id": userObjectID})
+		if err != nil || count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Goal not found"})
+			return
+		}
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":              input.Name,
+			"trigger":           input.Trigger,
+			"goal_id":           input.GoalID,
+			"percentage":        input.Percentage,
+			"amount":            input.Amount,
+			"match_category":    input.MatchCategory,
+			"match_description": input.MatchDescription,
+			"frequency":         input.Frequency,
+			"interval":          input.Interval,
+			"next_date":         input.NextDate,
+			"updated_at":        time.Now(),
+		},
+	}
+
+	if input.Active != nil {
+		update["$set"].(bson.M)["active"] = *input.Active
+	}
+
+	result, err := db.Client.Database("fintrack").Collection("goal_funding_rules").UpdateOne(ctx, bson.M{"_id": id, "user_id": userObjectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update funding rule"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Funding rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Funding rule updated"})
+}
+
+// DeleteFundingRule removes a funding rule; past contributions are kept
+func DeleteFundingRule(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	result, err := db.Client.Database("fintrack").Collection("goal_funding_rules").DeleteOne(ctx, bson.M{"_id": id, "user_id": userObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete funding rule"})
+		return
+	}
+
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Funding rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Funding rule deleted"})
+}
+
+// PreviewWaterfall shows how an amount would be split across goals by priority (?amount=)
+func PreviewWaterfall(c *gin.Context) {
+	var input struct {
+		Amount float64 `form:"amount" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	goals, err := goalsByPriority(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goals"})
+		return
+	}
+
+	allocations := allocateWaterfall(input.Amount, goals)
+	allocated := 0.0
+	for _, a := range allocations {
+		allocated += a.Amount
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"amount":      input.Amount,
+		"allocated":   math.Round(allocated*100) / 100,
+		"unallocated": math.Round((input.Amount-allocated)*100) / 100,
+		"allocations": allocations,
+	})
+}
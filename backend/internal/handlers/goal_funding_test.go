@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"fintrack-backend/internal/models"
+)
+
+func TestSortGoalsByPriority(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	goal := func(name string, priority, createdDay int) models.Goal {
+		return models.Goal{Name: name, Priority: priority, CreatedAt: base.AddDate(0, 0, createdDay)}
+	}
+	goals := []models.Goal{
+		goal("unset, newer", 0, 5),
+		goal("second", 2, 1),
+		goal("first, newer", 1, 3),
+		goal("unset, older", 0, 2),
+		goal("first, older", 1, 0),
+		goal("negative counts as unset", -1, 9),
+	}
+	sortGoalsByPriority(goals)
+
+	want := []string{"first, older", "first, newer", "second", "unset, older", "unset, newer", "negative counts as unset"}
+	for i, g := range goals {
+		if g.Name != want[i] {
+			t.Errorf("position %d = %q, want %q", i, g.Name, want[i])
+		}
+	}
+}
+
+func TestAllocateWaterfall(t *testing.T) {
+	goal := func(target, current float64, status string) models.Goal {
+		return models.Goal{ID: primitive.NewObjectID(), TargetAmount: target, CurrentAmount: current, Status: status}
+	}
+	backed := goal(1000, 0, models.GoalStatusActive)
+	backed.Accounts = []models.GoalAccountLink{{AccountID: primitive.NewObjectID(), Percentage: 100}}
+
+	tests := []struct {
+		name   string
+		amount float64
+		goals  []models.Goal
+		want   []float64 // Allocated amounts, in goal order, for the goals that receive money
+		skip   []int     // Indexes of goals that must receive nothing
+	}{
+		{
+			name:   "fills in order",
+			amount: 300,
+			goals:  []models.Goal{goal(100, 0, ""), goal(500, 0, models.GoalStatusActive), goal(500, 0, models.GoalStatusActive)},
+			want:   []float64{100, 200},
+			skip:   []int{2},
+		},
+		{
+			name:   "skips inactive, account-backed and full goals",
+			amount: 100,
+			goals: []models.Goal{
+				goal(500, 0, models.GoalStatusPaused),
+				goal(500, 500, models.GoalStatusAchieved),
+				goal(500, 0, models.GoalStatusArchived),
+				backed,
+				goal(500, 600, models.GoalStatusActive),
+				goal(500, 450, models.GoalStatusActive),
+				goal(500, 0, models.GoalStatusActive),
+			},
+			want: []float64{50, 50},
+			skip: []int{0, 1, 2, 3, 4},
+		},
+		{
+			name:   "leaves the rest unallocated",
+			amount: 1000,
+			goals:  []models.Goal{goal(100, 40, ""), goal(200, 0, "")},
+			want:   []float64{60, 200},
+		},
+		{
+			name:   "rounds to cents without losing any",
+			amount: 100,
+			goals:  []models.Goal{goal(33.333, 0, ""), goal(33.333, 0, ""), goal(50, 0, "")},
+			want:   []float64{33.33, 33.33, 33.34},
+		},
+		{
+			name:   "nothing to allocate",
+			amount: 0,
+			goals:  []models.Goal{goal(100, 0, "")},
+			skip:   []int{0},
+		},
+	}
+	for _, tt := range tests {
+		got := allocateWaterfall(tt.amount, tt.goals)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: allocations = %+v, want amounts %v", tt.name, got, tt.want)
+			continue
+		}
+		for i, a := range got {
+			if a.Amount != tt.want[i] {
+				t.Errorf("%s: allocation %d = %v, want %v", tt.name, i, a.Amount, tt.want[i])
+			}
+		}
+		for _, i := range tt.skip {
+			for _, a := range got {
+				if a.GoalID == tt.goals[i].ID {
+					t.Errorf("%s: goal %d received %v", tt.name, i, a.Amount)
+				}
+			}
+		}
+	}
+}
+
+func TestValidateFundingRule(t *testing.T) {
+	next := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rule models.GoalFundingRule
+		ok   bool
+	}{
+		{"income percentage", models.GoalFundingRule{Trigger: models.FundingTriggerIncome, Percentage: 10}, true},
+		{"income amount", models.GoalFundingRule{Trigger: models.FundingTriggerIncome, Amount: 50}, true},
+		{"income without share", models.GoalFundingRule{Trigger: models.FundingTriggerIncome}, false},
+		{"income over 100%", models.GoalFundingRule{Trigger: models.FundingTriggerIncome, Percentage: 101}, false},
+		{"schedule", models.GoalFundingRule{Trigger: models.FundingTriggerSchedule, Amount: 50, NextDate: next}, true},
+		{"schedule without amount", models.GoalFundingRule{Trigger: models.FundingTriggerSchedule, NextDate: next}, false},
+		{"schedule without date", models.GoalFundingRule{Trigger: models.FundingTriggerSchedule, Amount: 50}, false},
+		{"schedule with bad frequency", models.GoalFundingRule{Trigger: models.FundingTriggerSchedule, Amount: 50, NextDate: next, Frequency: "daily"}, false},
+		{"unknown trigger", models.GoalFundingRule{Trigger: "payday", Amount: 50}, false},
+	}
+	for _, tt := range tests {
+		rule := tt.rule
+		if msg := validateFundingRule(&rule); (msg == "") != tt.ok {
+			t.Errorf("%s: validateFundingRule = %q, want ok = %v", tt.name, msg, tt.ok)
+		}
+	}
+
+	// Scheduled rules default to monthly and drop any percentage
+	rule := models.GoalFundingRule{Trigger: models.FundingTriggerSchedule, Amount: 50, Percentage: 20, NextDate: next}
+	validateFundingRule(&rule)
+	if rule.Frequency != "monthly" || rule.Interval != 1 || rule.Percentage != 0 {
+		t.Errorf("defaults = %s/%d, percentage %v", rule.Frequency, rule.Interval, rule.Percentage)
+	}
+}
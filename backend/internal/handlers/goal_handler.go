@@ -4,6 +4,8 @@ import (
 	"context"
 	"fintrack-backend/internal/db"
 	"fintrack-backend/internal/models"
+	"fmt"
+	"log"
 	"net/http"
 	"time"
 
@@ -12,7 +14,50 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// GetGoals fetches all goals
+// goalsWithProgress loads the user's goals with their computed progress. Account-backed
+// goals take their balance from the accounts; their milestones are checked by
+// checkAccountGoalMilestones when those accounts change, not here.
+func goalsWithProgress(ctx context.Context, userID primitive.ObjectID, includeArchived bool, now time.Time) ([]models.GoalWithProgress, error) {
+	filter := bson.M{"user_id": userID}
+	if !includeArchived {
+		filter["status"] = bson.M{"$ne": models.GoalStatusArchived}
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("goals").Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("fetch goals: %w", err)
+	}
+
+	var goals []models.Goal
+	if err = cursor.All(ctx, &goals); err != nil {
+		return nil, fmt.Errorf("decode goals: %w", err)
+	}
+
+	pace, err := goalContributionPace(ctx, userID, goals, now)
+	if err != nil {
+		return nil, fmt.Errorf("fetch contribution pace: %w", err)
+	}
+
+	_, balances, err := accountBalances(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch account balances: %w", err)
+	}
+
+	var result []models.GoalWithProgress
+	for _, goal := range goals {
+		if len(goal.Accounts) > 0 {
+			goal.CurrentAmount = goalBalanceFromAccounts(goal, balances)
+		}
+		result = append(result, models.GoalWithProgress{
+			Goal:     goal,
+			Progress: computeGoalProgress(goal, pace[goal.ID], now),
+		})
+	}
+	return result, nil
+}
+
+// GetGoals fetches the user's goals with their computed progress.
+// Archived goals are only included with ?include_archived=true.
 func GetGoals(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -24,21 +69,14 @@ func GetGoals(c *gin.Context) {
 	}
 	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 
-	collection := db.Client.Database("fintrack").Collection("goals")
-
-	cursor, err := collection.Find(ctx, bson.M{"user_id": userObjectID})
+	result, err := goalsWithProgress(ctx, userObjectID, c.Query("include_archived") == "true", time.Now())
 	if err != nil {
+		log.Printf("Goals for user %s failed: %v", userObjectID.Hex(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goals"})
 		return
 	}
 
-	var goals []models.Goal
-	if err = cursor.All(ctx, &goals); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse goals"})
-		return
-	}
-
-	c.JSON(http.StatusOK, goals)
+	c.JSON(http.StatusOK, result)
 }
 
 // CreateGoal adds a new goal
@@ -59,6 +97,14 @@ func CreateGoal(c *gin.Context) {
 	goal.ID = primitive.NewObjectID()
 	goal.UserID = userObjectID
 	goal.CreatedAt = time.Now()
+	goal.Status = models.GoalStatusActive
+	goal.Milestones = nil
+	goal.AchievedAt = nil
+	goal.ArchivedAt = nil
+
+	// The balance is built from the contribution ledger, starting at zero
+	initialAmount := goal.CurrentAmount
+	goal.CurrentAmount = 0
 
 	// Default values if missing
 	if goal.Color == "" {
@@ -67,6 +113,10 @@ func CreateGoal(c *gin.Context) {
 	if goal.Icon == "" {
 		goal.Icon = "savings"
 	}
+	if goal.Priority < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Priority must not be negative"})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -78,10 +128,30 @@ func CreateGoal(c *gin.Context) {
 		return
 	}
 
+	if initialAmount > 0 {
+		updated, err := recordGoalContribution(ctx, &models.GoalContribution{
+			UserID: userObjectID,
+			GoalID: goal.ID,
+			Type:   models.ContributionOpening,
+			Amount: initialAmount,
+			Note:   "Initial balance",
+		}, nil)
+		if err != nil {
+			// Don't leave behind a goal without the balance that was asked for
+			if _, deleteErr := collection.DeleteOne(ctx, bson.M{"_id": goal.ID}); deleteErr != nil {
+				log.Printf("Goal %s: failed to remove after initial balance error: %v", goal.ID.Hex(), deleteErr)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record initial balance"})
+			return
+		}
+		goal = *updated
+	}
+
 	c.JSON(http.StatusCreated, goal)
 }
 
-// UpdateGoal updates a goal (e.g. adding savings)
+// UpdateGoal edits goal details. A changed current_amount is recorded as an
+// adjustment in the contribution ledger rather than overwriting the balance.
 func UpdateGoal(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := primitive.ObjectIDFromHex(idParam)
@@ -91,7 +161,13 @@ func UpdateGoal(c *gin.Context) {
 	}
 
 	var updateData struct {
-		CurrentAmount float64 `json:"current_amount"`
+		Name          *string    `json:"name"`
+		TargetAmount  *float64   `json:"target_amount"`
+		CurrentAmount *float64   `json:"current_amount"`
+		Color         *string    `json:"color"`
+		Icon          *string    `json:"icon"`
+		TargetDate    *time.Time `json:"target_date"`
+		Priority      *int       `json:"priority"`
 	}
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -103,10 +179,6 @@ func UpdateGoal(c *gin.Context) {
 
 	collection := db.Client.Database("fintrack").Collection("goals")
 
-	update := bson.M{
-		"$set": bson.M{"current_amount": updateData.CurrentAmount},
-	}
-
 	// Ensure user owns goal
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -115,16 +187,72 @@ func UpdateGoal(c *gin.Context) {
 	}
 	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": id, "user_id": userObjectID}, update)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update goal"})
+	var goal models.Goal
+	if err := collection.FindOne(ctx, bson.M{"_id": id, "user_id": userObjectID}).Decode(&goal); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
 		return
 	}
 
+	set := bson.M{}
+	if updateData.Name != nil {
+		set["name"] = *updateData.Name
+	}
+	if updateData.TargetAmount != nil {
+		set["target_amount"] = *updateData.TargetAmount
+	}
+	if updateData.Color != nil {
+		set["color"] = *updateData.Color
+	}
+	if updateData.Icon != nil {
+		set["icon"] = *updateData.Icon
+	}
+	if updateData.TargetDate != nil {
+		set["target_date"] = *updateData.TargetDate
+	}
+	if updateData.Priority != nil {
+		if *updateData.Priority < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Priority must not be negative"})
+			return
+		}
+		set["priority"] = *updateData.Priority
+	}
+
+	if len(set) > 0 {
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": id, "user_id": userObjectID}, bson.M{"$set": set})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update goal"})
+			return
+		}
+	}
+
+	if updateData.CurrentAmount != nil && *updateData.CurrentAmount != goal.CurrentAmount {
+		_, err = recordGoalContribution(ctx, &models.GoalContribution{
+			UserID: userObjectID,
+			GoalID: id,
+			Type:   models.ContributionAdjustment,
+			Amount: *updateData.CurrentAmount - goal.CurrentAmount,
+		}, &goal.CurrentAmount)
+		if err != nil {
+			status, msg := contributionErrorStatus(err)
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+	}
+
+	// A lowered target can complete milestones without any new contribution
+	if updateData.TargetAmount != nil {
+		if len(goal.Accounts) > 0 {
+			go checkAccountGoalMilestones(userObjectID)
+		} else if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&goal); err == nil {
+			checkGoalMilestones(ctx, goal)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Goal updated"})
 }
 
-// DeleteGoal removes a goal
+// DeleteGoal archives a goal, keeping its history. With ?hard=true the goal and
+// its contribution ledger are removed permanently.
 func DeleteGoal(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := primitive.ObjectIDFromHex(idParam)
@@ -145,11 +273,40 @@ func DeleteGoal(c *gin.Context) {
 	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 
 	collection := db.Client.Database("fintrack").Collection("goals")
-	_, err = collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userObjectID})
+
+	if c.Query("hard") != "true" {
+		result, err := collection.UpdateOne(ctx, bson.M{"_id": id, "user_id": userObjectID},
+			bson.M{"$set": bson.M{"status": models.GoalStatusArchived, "archived_at": time.Now()}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive goal"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Goal archived"})
+		return
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userObjectID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete goal"})
 		return
 	}
 
+	// Drop the goal's ledger along with it. This also runs when the goal is already
+	// gone, so repeating the request cleans up after a failed attempt.
+	ledger, err := db.Client.Database("fintrack").Collection("goal_contributions").DeleteMany(ctx, bson.M{"goal_id": id, "user_id": userObjectID})
+	if err != nil {
+		log.Printf("Goal %s: failed to delete contributions: %v", id.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete goal contributions"})
+		return
+	}
+	if result.DeletedCount == 0 && ledger.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Goal deleted"})
 }
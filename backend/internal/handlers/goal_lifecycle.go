@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+	"fintrack-backend/internal/notify"
+)
+
+// checkGoalMilestones records every milestone the goal has newly reached and emits a
+// notification for each. Reaching 100% also marks an active goal as achieved.
+// Milestones are claimed with a conditional $addToSet so each fires only once.
+func checkGoalMilestones(ctx context.Context, goal models.Goal) {
+	if goal.TargetAmount <= 0 || goal.State() == models.GoalStatusArchived {
+		return
+	}
+
+	pct := goal.CurrentAmount / goal.TargetAmount * 100
+	collection := db.Client.Database("fintrack").Collection("goals")
+
+	for _, milestone := range models.GoalMilestones {
+		if pct < float64(milestone) {
+			break
+		}
+
+		update := bson.M{"$addToSet": bson.M{"milestones_reached": milestone}}
+		if milestone == 100 && goal.State() == models.GoalStatusActive {
+			update["$set"] = bson.M{"status": models.GoalStatusAchieved, "achieved_at": time.Now()}
+		}
+
+		result, err := collection.UpdateOne(ctx,
+			bson.M{"_id": goal.ID, "milestones_reached": bson.M{"$ne": milestone}}, update)
+		if err != nil {
+			log.Printf("Goal %s: failed to record milestone %d: %v", goal.ID.Hex(), milestone, err)
+			continue
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+
+		notify.DispatchAsync(goalMilestoneMessage(goal, milestone))
+	}
+}
+
+// checkAccountGoalMilestones checks the milestones of the user's account-backed goals,
+// whose balances move with account transactions rather than contributions. It is
+// meant to run in the background after those transactions or links change.
+func checkAccountGoalMilestones(userID primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := db.Client.Database("fintrack").Collection("goals").Find(ctx, bson.M{
+		"user_id":    userID,
+		"accounts.0": bson.M{"$exists": true},
+		"status":     bson.M{"$ne": models.GoalStatusArchived},
+	})
+	if err != nil {
+		log.Printf("User %s: failed to load account-backed goals: %v", userID.Hex(), err)
+		return
+	}
+	var goals []models.Goal
+	if err = cursor.All(ctx, &goals); err != nil {
+		log.Printf("User %s: failed to load account-backed goals: %v", userID.Hex(), err)
+		return
+	}
+	if len(goals) == 0 {
+		return
+	}
+
+	_, balances, err := accountBalances(ctx, userID)
+	if err != nil {
+		log.Printf("User %s: failed to load account balances: %v", userID.Hex(), err)
+		return
+	}
+	for _, goal := range goals {
+		goal.CurrentAmount = goalBalanceFromAccounts(goal, balances)
+		checkGoalMilestones(ctx, goal)
+	}
+}
+
+func goalMilestoneMessage(goal models.Goal, milestone int) notify.Message {
+	title := fmt.Sprintf("%s is %d%% funded", goal.Name, milestone)
+	body := fmt.Sprintf("You have saved %.2f of %.2f for %s.", goal.CurrentAmount, goal.TargetAmount, goal.Name)
+	msgType := "goal_milestone"
+	if milestone == 100 {
+		title = fmt.Sprintf("Goal achieved: %s", goal.Name)
+		body = fmt.Sprintf("Congratulations! You reached your target of %.2f for %s.", goal.TargetAmount, goal.Name)
+		msgType = "goal_achieved"
+	}
+
+	return notify.Message{
+		UserID: goal.UserID,
+		Type:   msgType,
+		Title:  title,
+		Body:   body,
+		Data: map[string]interface{}{
+			"goal_id":   goal.ID.Hex(),
+			"milestone": milestone,
+			"current":   goal.CurrentAmount,
+			"target":    goal.TargetAmount,
+		},
+	}
+}
+
+// UpdateGoalStatus moves a goal between active, paused and archived.
+// "achieved" is set automatically when the goal reaches its target.
+func UpdateGoalStatus(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		Status string `json:"status" binding:"required,oneof=active paused archived"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	set := bson.M{"status": input.Status}
+	update := bson.M{"$set": set}
+	switch input.Status {
+	case models.GoalStatusArchived:
+		set["archived_at"] = time.Now()
+	default:
+		update["$unset"] = bson.M{"archived_at": ""}
+	}
+
+	result, err := db.Client.Database("fintrack").Collection("goals").UpdateOne(ctx, bson.M{"_id": id, "user_id": userObjectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update goal"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Goal status updated", "status": input.Status})
+}
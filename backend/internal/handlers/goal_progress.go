@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+const (
+	// Window of contributions used to measure the saving pace
+	goalPaceMonths = 3
+	daysPerMonth   = 30.44
+)
+
+// goalContributionPace returns the average net monthly contribution per goal over
+// the last goalPaceMonths months, or over the goal's lifetime when it is younger.
+// Opening balances and manual adjustments are not saving and do not count.
+func goalContributionPace(ctx context.Context, userID primitive.ObjectID, goals []models.Goal, now time.Time) (map[primitive.ObjectID]float64, error) {
+	since := now.AddDate(0, -goalPaceMonths, 0)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "user_id", Value: userID},
+			{Key: "type", Value: bson.D{{Key: "$in", Value: bson.A{models.ContributionDeposit, models.ContributionWithdraw}}}},
+			// Opening balances recorded before they had their own type
+			{Key: "note", Value: bson.D{{Key: "$ne", Value: "Initial balance"}}},
+			{Key: "date", Value: bson.D{{Key: "$gte", Value: since}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$goal_id"},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+		}}},
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("goal_contributions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		GoalID primitive.ObjectID `bson:"_id"`
+		Total  float64            `bson:"total"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	totals := make(map[primitive.ObjectID]float64)
+	for _, row := range rows {
+		totals[row.GoalID] = row.Total
+	}
+
+	pace := make(map[primitive.ObjectID]float64)
+	for _, goal := range goals {
+		if total, ok := totals[goal.ID]; ok {
+			pace[goal.ID] = total / goalPaceWindow(goal, now)
+		}
+	}
+	return pace, nil
+}
+
+// goalPaceWindow is the number of months the pace of goal is averaged over: its
+// age, at least one month so a new goal's first deposit is not extrapolated, and
+// at most goalPaceMonths
+func goalPaceWindow(goal models.Goal, now time.Time) float64 {
+	months := now.Sub(goal.CreatedAt).Hours() / 24 / daysPerMonth
+	return math.Min(math.Max(months, 1), goalPaceMonths)
+}
+
+// computeGoalProgress derives the required contribution, projected completion and
+// on-track status of a goal from its monthly saving pace
+func computeGoalProgress(goal models.Goal, monthlyPace float64, now time.Time) models.GoalProgress {
+	remaining := math.Max(goal.TargetAmount-goal.CurrentAmount, 0)
+
+	progress := models.GoalProgress{
+		Remaining:   math.Round(remaining*100) / 100,
+		MonthlyPace: math.Round(monthlyPace*100) / 100,
+	}
+	if goal.TargetAmount > 0 {
+		progress.Percentage = math.Round(math.Min(goal.CurrentAmount/goal.TargetAmount*100, 100))
+	}
+
+	if goal.TargetAmount > 0 && remaining == 0 {
+		progress.Status = models.GoalProgressAchieved
+		return progress
+	}
+
+	if monthlyPace > 0 {
+		days := remaining / monthlyPace * daysPerMonth
+		completion := now.Add(time.Duration(days * 24 * float64(time.Hour)))
+		progress.ProjectedCompletion = &completion
+	}
+
+	if goal.TargetDate == nil {
+		progress.Status = models.GoalProgressNoDeadline
+		return progress
+	}
+
+	monthsLeft := goal.TargetDate.Sub(now).Hours() / 24 / daysPerMonth
+	if monthsLeft < 1 {
+		// Due this month (or overdue): everything remaining is needed now
+		progress.RequiredMonthly = progress.Remaining
+	} else {
+		progress.RequiredMonthly = math.Round(remaining/monthsLeft*100) / 100
+	}
+
+	if progress.ProjectedCompletion != nil && !progress.ProjectedCompletion.After(*goal.TargetDate) {
+		progress.Status = models.GoalProgressOnTrack
+	} else {
+		progress.Status = models.GoalProgressBehind
+	}
+	return progress
+}
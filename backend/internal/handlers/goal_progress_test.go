@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"fintrack-backend/internal/models"
+)
+
+func TestGoalPaceWindow(t *testing.T) {
+	now := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		created time.Time
+		want    float64
+	}{
+		{"created this week", now.AddDate(0, 0, -3), 1},
+		{"two months old", now.Add(-time.Duration(2 * daysPerMonth * 24 * float64(time.Hour))), 2},
+		{"a year old", now.AddDate(-1, 0, 0), goalPaceMonths},
+		{"no creation date", time.Time{}, goalPaceMonths},
+	}
+	for _, tt := range tests {
+		got := goalPaceWindow(models.Goal{CreatedAt: tt.created}, now)
+		if got < tt.want-0.001 || got > tt.want+0.001 {
+			t.Errorf("%s: goalPaceWindow = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComputeGoalProgress(t *testing.T) {
+	now := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+	inMonths := func(m float64) time.Time {
+		return now.Add(time.Duration(m * daysPerMonth * 24 * float64(time.Hour)))
+	}
+	date := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name       string
+		goal       models.Goal
+		pace       float64
+		status     string
+		required   float64
+		projection *time.Time // nil when no completion can be projected
+	}{
+		{
+			name:       "no deadline",
+			goal:       models.Goal{TargetAmount: 1000, CurrentAmount: 400},
+			pace:       100,
+			status:     models.GoalProgressNoDeadline,
+			projection: date(inMonths(6)),
+		},
+		{
+			name:   "no deadline and no saving",
+			goal:   models.Goal{TargetAmount: 1000, CurrentAmount: 400},
+			status: models.GoalProgressNoDeadline,
+		},
+		{
+			name:       "on track",
+			goal:       models.Goal{TargetAmount: 1500, CurrentAmount: 300, TargetDate: date(inMonths(12))},
+			pace:       200,
+			status:     models.GoalProgressOnTrack,
+			required:   100,
+			projection: date(inMonths(6)),
+		},
+		{
+			name:       "projected exactly on the target date",
+			goal:       models.Goal{TargetAmount: 1000, CurrentAmount: 400, TargetDate: date(inMonths(6))},
+			pace:       100,
+			status:     models.GoalProgressOnTrack,
+			required:   100,
+			projection: date(inMonths(6)),
+		},
+		{
+			name:       "behind",
+			goal:       models.Goal{TargetAmount: 1500, CurrentAmount: 300, TargetDate: date(inMonths(6))},
+			pace:       100,
+			status:     models.GoalProgressBehind,
+			required:   200,
+			projection: date(inMonths(12)),
+		},
+		{
+			name:     "zero pace with a deadline",
+			goal:     models.Goal{TargetAmount: 1500, CurrentAmount: 300, TargetDate: date(inMonths(12))},
+			status:   models.GoalProgressBehind,
+			required: 100,
+		},
+		{
+			name:     "withdrawing faster than saving",
+			goal:     models.Goal{TargetAmount: 1500, CurrentAmount: 300, TargetDate: date(inMonths(12))},
+			pace:     -50,
+			status:   models.GoalProgressBehind,
+			required: 100,
+		},
+		{
+			name:       "due within a month",
+			goal:       models.Goal{TargetAmount: 1000, CurrentAmount: 500, TargetDate: date(now.AddDate(0, 0, 10))},
+			pace:       100,
+			status:     models.GoalProgressBehind,
+			required:   500,
+			projection: date(inMonths(5)),
+		},
+		{
+			name:     "overdue",
+			goal:     models.Goal{TargetAmount: 1000, CurrentAmount: 500, TargetDate: date(now.AddDate(0, -1, 0))},
+			status:   models.GoalProgressBehind,
+			required: 500,
+		},
+		{
+			name:   "achieved",
+			goal:   models.Goal{TargetAmount: 1000, CurrentAmount: 1200, TargetDate: date(now.AddDate(0, -1, 0))},
+			pace:   100,
+			status: models.GoalProgressAchieved,
+		},
+	}
+	for _, tt := range tests {
+		got := computeGoalProgress(tt.goal, tt.pace, now)
+		if got.Status != tt.status {
+			t.Errorf("%s: status = %q, want %q", tt.name, got.Status, tt.status)
+		}
+		if got.RequiredMonthly != tt.required {
+			t.Errorf("%s: required monthly = %v, want %v", tt.name, got.RequiredMonthly, tt.required)
+		}
+		switch {
+		case tt.projection == nil && got.ProjectedCompletion != nil:
+			t.Errorf("%s: projected completion = %v, want none", tt.name, got.ProjectedCompletion)
+		case tt.projection != nil && (got.ProjectedCompletion == nil || !got.ProjectedCompletion.Equal(*tt.projection)):
+			t.Errorf("%s: projected completion = %v, want %v", tt.name, got.ProjectedCompletion, tt.projection)
+		}
+	}
+}
+
+func TestComputeGoalProgressAmounts(t *testing.T) {
+	now := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	got := computeGoalProgress(models.Goal{TargetAmount: 1000, CurrentAmount: 333.333}, 123.456, now)
+	if got.Percentage != 33 || got.Remaining != 666.67 || got.MonthlyPace != 123.46 {
+		t.Errorf("progress = %+v, want 33%%, 666.67 remaining and a pace of 123.46", got)
+	}
+
+	got = computeGoalProgress(models.Goal{TargetAmount: 1000, CurrentAmount: 1500}, 0, now)
+	if got.Percentage != 100 || got.Remaining != 0 {
+		t.Errorf("overfunded progress = %+v, want 100%% and nothing remaining", got)
+	}
+
+	// Without a target there is nothing to achieve or measure against
+	got = computeGoalProgress(models.Goal{}, 0, now)
+	if got.Percentage != 0 || got.Status != models.GoalProgressNoDeadline {
+		t.Errorf("progress without target = %+v", got)
+	}
+}
@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+
+	"fintrack-backend/internal/auth"
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/email"
+	"fintrack-backend/internal/models"
+)
+
+const (
+	// How long a user has to confirm linking a provider to their existing account
+	linkRequestTTL = 15 * time.Minute
+	// Password guesses allowed per link request
+	linkRequestAttempts = 5
+	// How long the URL that starts linking from account settings stays valid
+	linkIntentTTL = 2 * time.Minute
+)
+
+var (
+	errIdentityInUse  = errors.New("identity is linked to another account")
+	errProviderLinked = errors.New("another account at this provider is already linked")
+)
+
+// providerProfile is what a login provider tells us about the user
+type providerProfile struct {
+	Provider      string
+	Subject       string // The provider's stable user ID
+	Email         string
+	EmailVerified bool
+	PrivateEmail  bool // Apple Hide My Email relay address
+	Name          string
+}
+
+// finishProviderLogin resolves a completed provider login and redirects to the web
+// app. When linkUserID is set the identity is linked to that user instead of
+// logging in. An email that matches an existing account is never merged silently:
+// the web app gets a link token that the account owner must confirm. New logins
+// whose email the provider has not verified are refused.
+func finishProviderLogin(ctx context.Context, c *gin.Context, profile providerProfile, linkUserID primitive.ObjectID) {
+	profile.Email = strings.ToLower(strings.TrimSpace(profile.Email))
+
+	if !linkUserID.IsZero() {
+		switch err := linkIdentity(ctx, linkUserID, profile); {
+		case errors.Is(err, errIdentityInUse):
+			redirectOAuthResult(ctx, c, primitive.NilObjectID, "identity_in_use")
+		case errors.Is(err, errProviderLinked):
+			redirectOAuthResult(ctx, c, primitive.NilObjectID, "provider_already_linked")
+		case err != nil:
+			redirectOAuthResult(ctx, c, primitive.NilObjectID, "server_error")
+		default:
+			redirectToApp(c, url.Values{"linked": {profile.Provider}})
+		}
+		return
+	}
+
+	identities := db.Client.Database("fintrack").Collection("identities")
+	var identity models.Identity
+	err := identities.FindOne(ctx, bson.M{"provider": profile.Provider, "subject": profile.Subject}).Decode(&identity)
+	if err == nil {
+		redirectOAuthResult(ctx, c, identity.UserID, "")
+		return
+	}
+	if err != mongo.ErrNoDocuments {
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "server_error")
+		return
+	}
+
+	if profile.Email == "" {
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "email_required")
+		return
+	}
+	// An unconfirmed address must not create or claim an account, or anyone could
+	// register someone else's email through the provider before its owner signs up
+	if !profile.EmailVerified {
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "email_unverified")
+		return
+	}
+
+	var user models.User
+	err = db.GetCollection("users").FindOne(ctx, bson.M{"email": profile.Email}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		user, err = createProviderUser(ctx, profile)
+		if err != nil {
+			redirectOAuthResult(ctx, c, primitive.NilObjectID, "server_error")
+			return
+		}
+		redirectOAuthResult(ctx, c, user.ID, "")
+		return
+	}
+	if err != nil {
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "server_error")
+		return
+	}
+
+	// Accounts created through this provider before identities were recorded
+	// already belong to it. Password accounts the old Google login merged by
+	// email also carry the provider, so those still need confirming.
+	if user.Provider == profile.Provider && user.Password == "" {
+		linked, err := identities.CountDocuments(ctx, bson.M{"user_id": user.ID, "provider": profile.Provider})
+		if err == nil && linked == 0 {
+			if err := linkIdentity(ctx, user.ID, profile); err == nil {
+				redirectOAuthResult(ctx, c, user.ID, "")
+				return
+			}
+		}
+	}
+
+	token, err := createLinkRequest(ctx, user.ID, profile)
+	if err != nil {
+		redirectOAuthResult(ctx, c, primitive.NilObjectID, "server_error")
+		return
+	}
+	redirectToApp(c, url.Values{
+		"error":      {"link_required"},
+		"link_token": {token},
+		"provider":   {profile.Provider},
+		"email":      {profile.Email},
+	})
+}
+
+// createProviderUser signs up a new user from a provider login
+func createProviderUser(ctx context.Context, profile providerProfile) (models.User, error) {
+	now := time.Now()
+	user := models.User{
+		ID:            primitive.NewObjectID(),
+		Name:          profile.Name,
+		Email:         profile.Email,
+		EmailVerified: profile.EmailVerified, // The provider has already confirmed the address
+		Provider:      profile.Provider,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if _, err := db.GetCollection("users").InsertOne(ctx, user); err != nil {
+		return models.User{}, err
+	}
+	if err := linkIdentity(ctx, user.ID, profile); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// linkIdentity records that the provider account belongs to userID. Linking the
+// same identity again is a no-op.
+func linkIdentity(ctx context.Context, userID primitive.ObjectID, profile providerProfile) error {
+	identities := db.Client.Database("fintrack").Collection("identities")
+
+	var existing models.Identity
+	err := identities.FindOne(ctx, bson.M{"provider": profile.Provider, "subject": profile.Subject}).Decode(&existing)
+	if err == nil {
+		if existing.UserID != userID {
+			return errIdentityInUse
+		}
+		return nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	_, err = identities.InsertOne(ctx, models.Identity{
+		ID:           primitive.NewObjectID(),
+		UserID:       userID,
+		Provider:     profile.Provider,
+		Subject:      profile.Subject,
+		Email:        profile.Email,
+		PrivateEmail: profile.PrivateEmail,
+		CreatedAt:    time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// Either a concurrent link of the same identity, or the user already has
+		// a different account at this provider
+		if identities.FindOne(ctx, bson.M{"provider": profile.Provider, "subject": profile.Subject}).Decode(&existing) == nil {
+			if existing.UserID != userID {
+				return errIdentityInUse
+			}
+			return nil
+		}
+		return errProviderLinked
+	}
+	return err
+}
+
+// createLinkRequest stores a pending link of the provider identity to userID and
+// returns the token the web app confirms it with
+func createLinkRequest(ctx context.Context, userID primitive.ObjectID, profile providerProfile) (string, error) {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	_, err = db.Client.Database("fintrack").Collection("link_requests").InsertOne(ctx, models.LinkRequest{
+		ID:           primitive.NewObjectID(),
+		UserID:       userID,
+		Provider:     profile.Provider,
+		Subject:      profile.Subject,
+		Email:        profile.Email,
+		PrivateEmail: profile.PrivateEmail,
+		TokenHash:    hash,
+		ExpiresAt:    now.Add(linkRequestTTL),
+		CreatedAt:    now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConfirmAccountLink links a provider to an existing account after the owner
+// proves it with the account's password, then logs them in. Two-factor
+// authentication still applies.
+func ConfirmAccountLink(c *gin.Context) {
+	var input struct {
+		LinkToken string `json:"link_token" binding:"required"`
+		Password  string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Count the attempt before checking the password, so guesses are capped per request
+	requests := db.Client.Database("fintrack").Collection("link_requests")
+	var request models.LinkRequest
+	err := requests.FindOneAndUpdate(ctx,
+		bson.M{
+			"token_hash": auth.HashToken(input.LinkToken),
+			"expires_at": bson.M{"$gt": time.Now()},
+			"attempts":   bson.M{"$lt": linkRequestAttempts},
+		},
+		bson.M{"$inc": bson.M{"attempts": 1}}).Decode(&request)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Link expired, please log in again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link account"})
+		return
+	}
+
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": request.UserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Link expired, please log in again"})
+		return
+	}
+	if user.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This account has no password. Log in with your existing provider and link this one from your account settings"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+
+	// The request must not be usable twice
+	result, err := requests.DeleteOne(ctx, bson.M{"_id": request.ID})
+	if err != nil || result.DeletedCount == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Link expired, please log in again"})
+		return
+	}
+
+	profile := providerProfile{Provider: request.Provider, Subject: request.Subject, Email: request.Email, PrivateEmail: request.PrivateEmail}
+	switch err := linkIdentity(ctx, user.ID, profile); {
+	case errors.Is(err, errIdentityInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "This login is already linked to another account"})
+		return
+	case errors.Is(err, errProviderLinked):
+		c.JSON(http.StatusConflict, gin.H{"error": "A different account at this provider is already linked"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link account"})
+		return
+	}
+
+	completeLogin(ctx, c, user, "Account linked")
+}
+
+// GetIdentities lists the providers linked to the current user and whether they
+// also have a password
+func GetIdentities(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": userObjectID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("identities").Find(ctx, bson.M{"user_id": userObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked logins"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	identities := []models.Identity{}
+	if err := cursor.All(ctx, &identities); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode linked logins"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": identities, "has_password": user.Password != ""})
+}
+
+// LinkProvider returns the URL that starts linking a provider to the current
+// user. The browser has to navigate to it, so the user is carried in a short-lived
+// signed token rather than the Authorization header. The same token is set in an
+// HTTP-only cookie and must come back with the URL, so a link URL minted by
+// someone else cannot link the provider account of whoever opens it.
+func LinkProvider(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	provider := c.Param("provider")
+	if provider != "google" && provider != "apple" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported provider"})
+		return
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(linkIntentTTL).Unix(), 10)
+	token := auth.Sign("link-"+provider, userID.(string)+"."+expires)
+
+	secure := strings.HasPrefix(email.APIURL(), "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(linkIntentCookie(provider), token, int(linkIntentTTL.Seconds()), "/api/auth/"+provider, "", secure, true)
+	c.JSON(http.StatusOK, gin.H{"url": email.APIURL() + "/api/auth/" + provider + "?link_token=" + url.QueryEscape(token)})
+}
+
+func linkIntentCookie(provider string) string {
+	return "fintrack_link_" + provider
+}
+
+// linkIntent returns the user a provider login was started for by LinkProvider,
+// or primitive.NilObjectID for a plain login
+func linkIntent(c *gin.Context, provider string) (primitive.ObjectID, error) {
+	token := c.Query("link_token")
+	if token == "" {
+		return primitive.NilObjectID, nil
+	}
+
+	cookie, err := c.Cookie(linkIntentCookie(provider))
+	c.SetCookie(linkIntentCookie(provider), "", -1, "/api/auth/"+provider, "", false, true)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(token)) != 1 {
+		return primitive.NilObjectID, errInvalidOAuthState
+	}
+
+	value, ok := auth.Verify("link-"+provider, token)
+	if !ok {
+		return primitive.NilObjectID, errInvalidOAuthState
+	}
+	hex, expires, ok := strings.Cut(value, ".")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if !ok || err != nil || time.Now().Unix() > unix {
+		return primitive.NilObjectID, errInvalidOAuthState
+	}
+	userID, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return primitive.NilObjectID, errInvalidOAuthState
+	}
+	return userID, nil
+}
+
+// UnlinkProvider removes a linked provider, as long as the user keeps another way
+// to log in
+func UnlinkProvider(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+	provider := c.Param("provider")
+
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": userObjectID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Delete first and put the identity back if nothing is left, so two concurrent
+	// unlinks cannot both pass a check made before either deleted
+	identities := db.Client.Database("fintrack").Collection("identities")
+	var identity models.Identity
+	err := identities.FindOneAndDelete(ctx, bson.M{"user_id": userObjectID, "provider": provider}).Decode(&identity)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Provider not linked"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink provider"})
+		return
+	}
+
+	if user.Password == "" {
+		remaining, countErr := identities.CountDocuments(ctx, bson.M{"user_id": userObjectID})
+		if countErr != nil || remaining == 0 {
+			if _, err := identities.InsertOne(ctx, identity); err != nil {
+				log.Printf("Failed to restore %s identity for user %s: %v", provider, userObjectID.Hex(), err)
+			}
+			if countErr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink provider"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Set a password or link another provider before unlinking this one"})
+			return
+		}
+	}
+
+	// Otherwise the next login with this provider would be treated as the
+	// account's original sign-up and linked again without confirmation
+	if user.Provider == provider {
+		if _, err := db.GetCollection("users").UpdateOne(ctx, bson.M{"_id": userObjectID},
+			bson.M{"$unset": bson.M{"provider": ""}, "$set": bson.M{"updated_at": time.Now()}}); err != nil {
+			log.Printf("Failed to clear provider for user %s: %v", userObjectID.Hex(), err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Provider unlinked"})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// linkURL calls LinkProvider for userID and returns the link URL and cookie it issued
+func linkURL(t *testing.T, userID primitive.ObjectID) (*url.URL, *http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/identities/google/link", nil)
+	c.Params = gin.Params{{Key: "provider", Value: "google"}}
+	c.Set("userID", userID.Hex())
+	LinkProvider(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("LinkProvider status = %d", w.Code)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != linkIntentCookie("google") || !cookies[0].HttpOnly {
+		t.Fatalf("LinkProvider cookies = %v", cookies)
+	}
+	var body struct {
+		URL string `json:"url"`
+	}
+	decodeJSON(t, w, &body)
+	u, err := url.Parse(body.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u, cookies[0]
+}
+
+func TestLinkIntent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := primitive.NewObjectID()
+	u, cookie := linkURL(t, userID)
+	_, otherCookie := linkURL(t, primitive.NewObjectID())
+
+	tests := []struct {
+		name    string
+		query   string
+		cookie  *http.Cookie
+		want    primitive.ObjectID
+		wantErr bool
+	}{
+		{name: "plain login", query: "", want: primitive.NilObjectID},
+		{name: "token with its cookie", query: u.RawQuery, cookie: cookie, want: userID},
+		{name: "token without cookie", query: u.RawQuery, wantErr: true},
+		{name: "token with another user's cookie", query: u.RawQuery, cookie: otherCookie, wantErr: true},
+		{name: "tampered token", query: "link_token=" + url.QueryEscape(userID.Hex()+".9999999999.AAAA"), cookie: cookie, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/auth/google?"+tt.query, nil)
+			if tt.cookie != nil {
+				c.Request.AddCookie(tt.cookie)
+			}
+			got, err := linkIntent(c, "google")
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("linkIntent = %v, %v; want %v, err=%v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+// computeNetWorth sums account balances, goal savings and manually valued assets.
+// Account-backed goals are left out because their money is already counted in the
+// accounts, and archived goals no longer hold savings.
+func computeNetWorth(ctx context.Context, userID primitive.ObjectID) (*models.NetWorth, error) {
+	accounts, balances, err := accountBalances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.NetWorthItem
+	for _, a := range accounts {
+		items = append(items, models.NetWorthItem{
+			Source: models.NetWorthSourceAccount,
+			ID:     a.ID,
+			Name:   a.Name,
+			Type:   a.Type,
+			Value:  balances[a.ID],
+		})
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("goals").Find(ctx, bson.M{
+		"user_id":    userID,
+		"accounts.0": bson.M{"$exists": false},
+		"status":     bson.M{"$ne": models.GoalStatusArchived},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var goals []models.Goal
+	if err = cursor.All(ctx, &goals); err != nil {
+		return nil, err
+	}
+	for _, g := range goals {
+		items = append(items, models.NetWorthItem{
+			Source: models.NetWorthSourceGoal,
+			ID:     g.ID,
+			Name:   g.Name,
+			Type:   "goal",
+			Value:  g.CurrentAmount,
+		})
+	}
+
+	cursor, err = db.Client.Database("fintrack").Collection("assets").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	var assets []models.Asset
+	if err = cursor.All(ctx, &assets); err != nil {
+		return nil, err
+	}
+	for _, a := range assets {
+		value := a.Value
+		if a.Kind == models.AssetKindLiability {
+			value = -value
+		}
+		items = append(items, models.NetWorthItem{
+			Source: models.NetWorthSourceAsset,
+			ID:     a.ID,
+			Name:   a.Name,
+			Type:   a.Category,
+			Value:  value,
+		})
+	}
+
+	worth := &models.NetWorth{
+		BySource: map[string]float64{
+			models.NetWorthSourceAccount: 0,
+			models.NetWorthSourceGoal:    0,
+			models.NetWorthSourceAsset:   0,
+		},
+		Items: []models.NetWorthItem{},
+	}
+	for _, item := range items {
+		item.Value = math.Round(item.Value*100) / 100
+		if item.Value >= 0 {
+			worth.Assets += item.Value
+		} else {
+			worth.Liabilities -= item.Value
+		}
+		worth.BySource[item.Source] += item.Value
+		worth.Items = append(worth.Items, item)
+	}
+	for source, total := range worth.BySource {
+		worth.BySource[source] = math.Round(total*100) / 100
+	}
+	worth.Assets = math.Round(worth.Assets*100) / 100
+	worth.Liabilities = math.Round(worth.Liabilities*100) / 100
+	worth.NetWorth = math.Round((worth.Assets-worth.Liabilities)*100) / 100
+
+	sort.SliceStable(worth.Items, func(i, j int) bool {
+		return math.Abs(worth.Items[i].Value) > math.Abs(worth.Items[j].Value)
+	})
+	return worth, nil
+}
+
+// saveNetWorthSnapshot stores the user's net worth for the current day in their time
+// zone, replacing an earlier snapshot of the same day
+func saveNetWorthSnapshot(ctx context.Context, user models.User, now time.Time) error {
+	worth, err := computeNetWorth(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	date := now.In(timezoneLocation(user.Timezone)).Format("2006-01-02")
+
+	_, err = db.Client.Database("fintrack").Collection("net_worth_snapshots").UpdateOne(ctx,
+		bson.M{"user_id": user.ID, "date": date},
+		bson.M{
+			"$set": bson.M{
+				"assets":      worth.Assets,
+				"liabilities": worth.Liabilities,
+				"net_worth":   worth.NetWorth,
+				"by_source":   worth.BySource,
+				"taken_at":    now,
+			},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// RecordNetWorthSnapshots stores today's net worth of every user. It is run daily
+// by the scheduler.
+func RecordNetWorthSnapshots(ctx context.Context) error {
+	cursor, err := db.GetCollection("users").Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"_id": 1, "timezone": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now()
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		if err := saveNetWorthSnapshot(ctx, user, now); err != nil {
+			log.Printf("Net worth snapshot for user %s failed: %v", user.ID.Hex(), err)
+		}
+	}
+	return cursor.Err()
+}
+
+// GetNetWorth returns the current net worth with its composition and the snapshot
+// history (?from=&to= as YYYY-MM-DD, default the last 12 months)
+func GetNetWorth(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	loc := userLocation(ctx, userObjectID)
+	today := time.Now().In(loc)
+	from := c.DefaultQuery("from", today.AddDate(-1, 0, 0).Format("2006-01-02"))
+	to := c.DefaultQuery("to", today.Format("2006-01-02"))
+	for _, d := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must be in YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	worth, err := computeNetWorth(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute net worth"})
+		return
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "date", Value: 1}})
+
+	// Dates are zero-padded, so string comparison orders them chronologically
+	cursor, err := db.Client.Database("fintrack").Collection("net_worth_snapshots").Find(ctx, bson.M{
+		"user_id": userObjectID,
+		"date":    bson.M{"$gte": from, "$lte": to},
+	}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch net worth history"})
+		return
+	}
+
+	history := []models.NetWorthSnapshot{}
+	if err = cursor.All(ctx, &history); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse net worth history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"current": worth,
+		"history": history,
+	})
+}
+
+// GetAssets lists the user's manually valued assets and liabilities
+func GetAssets(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	cursor, err := db.Client.Database("fintrack").Collection("assets").Find(ctx, bson.M{"user_id": userObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assets"})
+		return
+	}
+
+	assets := []models.Asset{}
+	if err = cursor.All(ctx, &assets); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse assets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, assets)
+}
+
+// validateAsset fills defaults and returns an error message for invalid input
+func validateAsset(asset *models.Asset) string {
+	if asset.Name == "" {
+		return "Name is required"
+	}
+	if asset.Kind == "" {
+		asset.Kind = models.AssetKindAsset
+	}
+	if asset.Kind != models.AssetKindAsset && asset.Kind != models.AssetKindLiability {
+		return "Kind must be asset or liability"
+	}
+	if asset.Value < 0 {
+		return "Value must not be negative; use kind liability for debts"
+	}
+	if asset.ValuedAt.IsZero() {
+		asset.ValuedAt = time.Now()
+	}
+	return ""
+}
+
+// CreateAsset adds a manually valued asset or liability
+func CreateAsset(c *gin.Context) {
+	var asset models.Asset
+	if err := c.ShouldBindJSON(&asset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateAsset(&asset); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	asset.ID = primitive.NewObjectID()
+	asset.UserID, _ = primitive.ObjectIDFromHex(userID.(string))
+	asset.CreatedAt = time.Now()
+	asset.UpdatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.Client.Database("fintrack").Collection("assets").InsertOne(ctx, asset); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create asset"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, asset)
+}
+
+// UpdateAsset edits an asset, typically to record a new valuation
+func UpdateAsset(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var input models.Asset
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateAsset(&input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":       input.Name,
+			"kind":       input.Kind,
+			"category":   input.Category,
+			"value":      input.Value,
+			"valued_at":  input.ValuedAt,
+			"note":       input.Note,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := db.Client.Database("fintrack").Collection("assets").UpdateOne(ctx, bson.M{"_id": id, "user_id": userObjectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update asset"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Asset updated"})
+}
+
+// DeleteAsset removes an asset; past snapshots keep its value
+func DeleteAsset(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	result, err := db.Client.Database("fintrack").Collection("assets").DeleteOne(ctx, bson.M{"_id": id, "user_id": userObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete asset"})
+		return
+	}
+
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Asset deleted"})
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/oauth2"
+
+	"fintrack-backend/internal/auth"
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/email"
+	"fintrack-backend/internal/models"
+)
+
+const (
+	// How long a provider login may take from redirect to callback
+	oauthStateTTL = 10 * time.Minute
+	// How long the web app has to exchange the one-time code
+	oauthCodeTTL = time.Minute
+)
+
+var errInvalidOAuthState = errors.New("invalid OAuth state")
+
+// oauthCallbackURL is the web app page providers' logins end on. It receives
+// ?code= on success or ?error= on failure.
+func oauthCallbackURL() string {
+	if u := os.Getenv("OAUTH_CALLBACK_URL"); u != "" {
+		return u
+	}
+	return email.AppURL() + "/auth/callback"
+}
+
+// oauthRedirectURL is the provider callback registered with the provider, e.g.
+// GOOGLE_REDIRECT_URL; it defaults to this server's own callback route
+func oauthRedirectURL(provider string) string {
+	if u := os.Getenv(strings.ToUpper(provider) + "_REDIRECT_URL"); u != "" {
+		return u
+	}
+	return email.APIURL() + "/api/auth/" + provider + "/callback"
+}
+
+func oauthStateCookie(provider string) string {
+	return "fintrack_oauth_" + provider
+}
+
+// startOAuth creates a random state and PKCE verifier and keeps them in a signed,
+// HTTP-only cookie for the callback.
+// linkUserID is set when a logged-in user is linking the provider to their account
+// and primitive.NilObjectID for a login.
+func startOAuth(c *gin.Context, provider string, linkUserID primitive.ObjectID) (state, verifier string) {
+	state = oauth2.GenerateVerifier() // Any high-entropy URL-safe string will do
+	verifier = oauth2.GenerateVerifier()
+
+	value := state + "." + verifier + "."
+	if !linkUserID.IsZero() {
+		value += linkUserID.Hex()
+	}
+
+	secure := strings.HasPrefix(oauthRedirectURL(provider), "https://")
+	if provider == "apple" {
+		// Apple posts the callback from its own site, which Lax cookies are not
+		// sent with; SameSite=None in turn requires Secure
+		c.SetSameSite(http.SameSiteNoneMode)
+		secure = true
+	} else {
+		c.SetSameSite(http.SameSiteLaxMode)
+	}
+	c.SetCookie(oauthStateCookie(provider), auth.Sign("oauth-"+provider, value),
+		int(oauthStateTTL.Seconds()), "/api/auth/"+provider, "", secure, true)
+
+	return state, verifier
+}
+
+// finishOAuth checks the state returned by the provider against the cookie set by
+// startOAuth, clears the cookie and returns the PKCE verifier along with the user
+// being linked, if any
+func finishOAuth(c *gin.Context, provider, state string) (string, primitive.ObjectID, error) {
+	cookie, err := c.Cookie(oauthStateCookie(provider))
+	c.SetCookie(oauthStateCookie(provider), "", -1, "/api/auth/"+provider, "", false, true)
+	if err != nil {
+		return "", primitive.NilObjectID, errInvalidOAuthState
+	}
+
+	value, ok := auth.Verify("oauth-"+provider, cookie)
+	if !ok {
+		return "", primitive.NilObjectID, errInvalidOAuthState
+	}
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 || state == "" || subtle.ConstantTimeCompare([]byte(parts[0]), []byte(state)) != 1 {
+		return "", primitive.NilObjectID, errInvalidOAuthState
+	}
+
+	linkUserID := primitive.NilObjectID
+	if parts[2] != "" {
+		if linkUserID, err = primitive.ObjectIDFromHex(parts[2]); err != nil {
+			return "", primitive.NilObjectID, errInvalidOAuthState
+		}
+	}
+	return parts[1], linkUserID, nil
+}
+
+// redirectOAuthResult sends the browser back to the web app with either a one-time
+// code for userID or an error code
+func redirectOAuthResult(ctx context.Context, c *gin.Context, userID primitive.ObjectID, errCode string) {
+	q := url.Values{}
+	if errCode == "" {
+		code, hash, err := auth.NewOpaqueToken()
+		if err == nil {
+			now := time.Now()
+			_, err = db.Client.Database("fintrack").Collection("oauth_codes").InsertOne(ctx, models.OAuthCode{
+				ID:        primitive.NewObjectID(),
+				UserID:    userID,
+				CodeHash:  hash,
+				ExpiresAt: now.Add(oauthCodeTTL),
+				CreatedAt: now,
+			})
+		}
+		if err != nil {
+			errCode = "server_error"
+		} else {
+			q.Set("code", code)
+		}
+	}
+	if errCode != "" {
+		q.Set("error", errCode)
+	}
+	redirectToApp(c, q)
+}
+
+// redirectToApp sends the browser to the web app's OAuth callback page with q
+func redirectToApp(c *gin.Context, q url.Values) {
+	target := oauthCallbackURL()
+	if strings.Contains(target, "?") {
+		target += "&" + q.Encode()
+	} else {
+		target += "?" + q.Encode()
+	}
+	c.Redirect(http.StatusFound, target)
+}
+
+// ExchangeOAuthCode trades the one-time code from a provider login for a session,
+// or a 2FA challenge when the user has two-factor authentication enabled
+func ExchangeOAuthCode(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Deleting the code as it is read makes it single-use
+	var code models.OAuthCode
+	err := db.Client.Database("fintrack").Collection("oauth_codes").FindOneAndDelete(ctx, bson.M{
+		"code_hash":  auth.HashToken(input.Code),
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&code)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired code"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange code"})
+		return
+	}
+
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": code.UserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired code"})
+		return
+	}
+
+	completeLogin(ctx, c, user, "Login successful")
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// oauthStart runs startOAuth and returns the state, verifier and cookie it issued
+func oauthStart(t *testing.T, provider string, linkUserID primitive.ObjectID) (string, string, *http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/auth/"+provider+"/login", nil)
+	state, verifier := startOAuth(c, provider, linkUserID)
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != oauthStateCookie(provider) {
+		t.Fatalf("startOAuth cookies = %v", cookies)
+	}
+	cookie := cookies[0]
+	if !cookie.HttpOnly || cookie.Path != "/api/auth/"+provider {
+		t.Errorf("state cookie = %+v, want HttpOnly with path /api/auth/%s", cookie, provider)
+	}
+	return state, verifier, cookie
+}
+
+// oauthFinish runs finishOAuth for a callback carrying state and cookie
+func oauthFinish(provider, state string, cookie *http.Cookie) (string, primitive.ObjectID, error) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/auth/"+provider+"/callback", nil)
+	if cookie != nil {
+		c.Request.AddCookie(cookie)
+	}
+	return finishOAuth(c, provider, state)
+}
+
+func TestOAuthStateRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	state, verifier, cookie := oauthStart(t, "google", primitive.NilObjectID)
+	gotVerifier, linkUserID, err := oauthFinish("google", state, cookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotVerifier != verifier || !linkUserID.IsZero() {
+		t.Errorf("finishOAuth = %q, %v; want %q and no link user", gotVerifier, linkUserID, verifier)
+	}
+
+	userID := primitive.NewObjectID()
+	state, _, cookie = oauthStart(t, "google", userID)
+	if _, linkUserID, err = oauthFinish("google", state, cookie); err != nil || linkUserID != userID {
+		t.Errorf("link user = %v, %v; want %v", linkUserID, err, userID)
+	}
+}
+
+func TestOAuthStateRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	state, _, cookie := oauthStart(t, "google", primitive.NewObjectID())
+	otherState, _, otherCookie := oauthStart(t, "google", primitive.NilObjectID)
+
+	tampered := *cookie
+	if cookie.Value[0] == 'A' {
+		tampered.Value = "B" + cookie.Value[1:]
+	} else {
+		tampered.Value = "A" + cookie.Value[1:]
+	}
+
+	// A state cookie from one provider is not accepted by another
+	_, _, appleCookie := oauthStart(t, "apple", primitive.NilObjectID)
+	appleCookie.Name = oauthStateCookie("google")
+
+	tests := []struct {
+		name   string
+		state  string
+		cookie *http.Cookie
+	}{
+		{"no cookie", state, nil},
+		{"empty state", "", cookie},
+		{"other login's state", otherState, cookie},
+		{"other login's cookie", state, otherCookie},
+		{"tampered cookie", state, &tampered},
+		{"other provider's cookie", state, appleCookie},
+	}
+	for _, tt := range tests {
+		if _, _, err := oauthFinish("google", tt.state, tt.cookie); err != errInvalidOAuthState {
+			t.Errorf("%s: err = %v, want errInvalidOAuthState", tt.name, err)
+		}
+	}
+}
+
+func TestOAuthStateCookieSameSite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Apple posts its callback cross-site, so its cookie must be sent with that
+	// request; other providers redirect with a top-level GET
+	_, _, cookie := oauthStart(t, "apple", primitive.NilObjectID)
+	if cookie.SameSite != http.SameSiteNoneMode || !cookie.Secure {
+		t.Errorf("apple cookie SameSite = %v, Secure = %v; want None and Secure", cookie.SameSite, cookie.Secure)
+	}
+	_, _, cookie = oauthStart(t, "google", primitive.NilObjectID)
+	if cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("google cookie SameSite = %v, want Lax", cookie.SameSite)
+	}
+}
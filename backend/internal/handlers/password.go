@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+
+	"fintrack-backend/internal/auth"
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/email"
+	"fintrack-backend/internal/models"
+)
+
+const (
+	// How long an emailed reset link can be used
+	passwordResetTTL = time.Hour
+	// Reset emails sent to one user per hour; further requests are silently dropped
+	passwordResetsPerHour = 3
+)
+
+// ForgotPassword emails a password reset link. The response is the same whether or
+// not the address belongs to an account, and the email is sent in the background so
+// response times do not tell either.
+func ForgotPassword(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	go passwordResetSender(strings.ToLower(strings.TrimSpace(input.Email)))
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for this email, a reset link has been sent"})
+}
+
+// passwordResetSender is called in the background by ForgotPassword; tests replace it
+var passwordResetSender = sendPasswordReset
+
+func sendPasswordReset(address string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"email": address}).Decode(&user); err != nil {
+		return
+	}
+
+	resets := db.Client.Database("fintrack").Collection("password_resets")
+	recent, err := resets.CountDocuments(ctx, bson.M{"user_id": user.ID, "created_at": bson.M{"$gt": time.Now().Add(-time.Hour)}})
+	if err != nil || recent >= passwordResetsPerHour {
+		return
+	}
+
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		log.Printf("Password reset for user %s failed: %v", user.ID.Hex(), err)
+		return
+	}
+
+	now := time.Now()
+	_, err = resets.InsertOne(ctx, models.PasswordReset{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(passwordResetTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		log.Printf("Password reset for user %s could not be saved: %v", user.ID.Hex(), err)
+		return
+	}
+
+	data := map[string]string{
+		"URL":       email.AppURL() + "/reset-password?token=" + url.QueryEscape(token),
+		"ExpiresIn": "1 hour",
+	}
+	if err := email.Send(ctx, user, "", "password_reset", data); err != nil {
+		log.Printf("Password reset email for user %s could not be sent: %v", user.ID.Hex(), err)
+	}
+}
+
+// ResetPassword sets a new password using an emailed token. The token is consumed,
+// other outstanding reset links stop working and every session is logged out.
+func ResetPassword(c *gin.Context) {
+	var input struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=6"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Claim the token atomically so it works only once
+	now := time.Now()
+	resets := db.Client.Database("fintrack").Collection("password_resets")
+	var reset models.PasswordReset
+	err := resets.FindOneAndUpdate(ctx, claimTokenFilter(input.Token, now), bson.M{"$set": bson.M{"used_at": now}}).Decode(&reset)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error hashing password"})
+		return
+	}
+
+	// Following the emailed link also proves the user owns the address
+	result, err := db.GetCollection("users").UpdateOne(ctx,
+		bson.M{"_id": reset.UserID},
+		bson.M{"$set": bson.M{"password": string(hashedPassword), "email_verified": true, "updated_at": now}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		return
+	}
+
+	if _, err := resets.UpdateMany(ctx, bson.M{"user_id": reset.UserID, "used_at": nil}, bson.M{"$set": bson.M{"used_at": now}}); err != nil {
+		log.Printf("Failed to expire reset links for user %s: %v", reset.UserID.Hex(), err)
+	}
+	if err := revokeUserSessions(ctx, reset.UserID); err != nil {
+		log.Printf("Failed to revoke sessions for user %s: %v", reset.UserID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in"})
+}
+
+// claimTokenFilter matches the stored entry for an emailed token while it is unused
+// and unexpired. Only the hash is stored, so the raw token never reaches the database.
+func claimTokenFilter(token string, now time.Time) bson.M {
+	return bson.M{"token_hash": auth.HashToken(token), "used_at": nil, "expires_at": bson.M{"$gt": now}}
+}
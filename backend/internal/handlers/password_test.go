@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"fintrack-backend/internal/auth"
+)
+
+func TestClaimTokenFilter(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	filter := claimTokenFilter("raw-token", now)
+
+	if filter["token_hash"] != auth.HashToken("raw-token") {
+		t.Errorf("token_hash = %v, want the hash of the token", filter["token_hash"])
+	}
+	// Claimed tokens have used_at set, so a second claim finds nothing
+	if v, ok := filter["used_at"]; !ok || v != nil {
+		t.Errorf("used_at = %v, want nil", v)
+	}
+	if v, ok := filter["expires_at"].(bson.M); !ok || v["$gt"] != now {
+		t.Errorf("expires_at = %v, want $gt %v", filter["expires_at"], now)
+	}
+}
+
+func TestForgotPasswordResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sent := make(chan string, 1)
+	passwordResetSender = func(address string) { sent <- address }
+	defer func() { passwordResetSender = sendPasswordReset }()
+
+	forgot := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/forgot-password", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		ForgotPassword(c)
+		return w
+	}
+
+	// The response cannot tell whether the address has an account
+	var responses []string
+	for _, body := range []string{`{"email": "Known@Example.com"}`, `{"email": "nobody@example.com"}`} {
+		w := forgot(body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d", body, w.Code)
+		}
+		responses = append(responses, w.Body.String())
+		<-sent
+	}
+	if responses[0] != responses[1] {
+		t.Errorf("responses differ: %s vs %s", responses[0], responses[1])
+	}
+
+	forgot(`{"email": "Known@Example.com"}`)
+	if got := <-sent; got != "known@example.com" {
+		t.Errorf("reset sent to %q, want the normalized address", got)
+	}
+
+	for _, body := range []string{`{}`, `{"email": "not-an-email"}`, `not json`} {
+		if w := forgot(body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, w.Code)
+		}
+	}
+	select {
+	case got := <-sent:
+		t.Errorf("invalid request sent a reset to %q", got)
+	default:
+	}
+}
+
+func TestResetPasswordRejectsInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, body := range []string{
+		`{}`,
+		`{"password": "secret123"}`,
+		`{"token": "abc"}`,
+		`{"token": "abc", "password": "short"}`,
+		`not json`,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/reset-password", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		ResetPassword(c)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+var validFrequencies = map[string]bool{"weekly": true, "monthly": true, "yearly": true}
+
+// normalizeRecurringSchedule fills defaults and validates a schedule from user input
+func normalizeRecurringSchedule(s *models.RecurringSchedule) string {
+	if s.Frequency == "" {
+		s.Frequency = "monthly"
+	}
+	if !validFrequencies[s.Frequency] {
+		return "Frequency must be weekly, monthly or yearly"
+	}
+	if s.Interval <= 0 {
+		s.Interval = 1
+	}
+	if s.NextDate.IsZero() {
+		return "next_date is required"
+	}
+	if s.Type == "" {
+		if s.Amount >= 0 {
+			s.Type = "income"
+		} else {
+			s.Type = "expense"
+		}
+	}
+	return ""
+}
+
+// loadRecurringSchedules returns the user's active schedules, optionally restricted to one type
+func loadRecurringSchedules(ctx context.Context, userID primitive.ObjectID, txType string) ([]models.RecurringSchedule, error) {
+	filter := bson.M{"user_id": userID, "active": true}
+	if txType != "" {
+		filter["type"] = txType
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("recurring").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var schedules []models.RecurringSchedule
+	if err = cursor.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// GetRecurringSchedules lists the user's recurring schedules
+func GetRecurringSchedules(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "next_date", Value: 1}})
+
+	cursor, err := db.Client.Database("fintrack").Collection("recurring").Find(ctx, bson.M{"user_id": userObjectID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring schedules"})
+		return
+	}
+
+	schedules := []models.RecurringSchedule{}
+	if err = cursor.All(ctx, &schedules); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse recurring schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// CreateRecurringSchedule adds a new recurring schedule
+func CreateRecurringSchedule(c *gin.Context) {
+	var schedule models.RecurringSchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if msg := normalizeRecurringSchedule(&schedule); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	schedule.ID = primitive.NewObjectID()
+	schedule.UserID, _ = primitive.ObjectIDFromHex(userID.(string))
+	schedule.Active = true
+	schedule.CreatedAt = time.Now()
+	schedule.UpdatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.Client.Database("fintrack").Collection("recurring").InsertOne(ctx, schedule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring schedule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// UpdateRecurringSchedule replaces the editable fields of a recurring schedule
+func UpdateRecurringSchedule(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	// Active is a pointer so that omitting it leaves the schedule's state unchanged
+	var input struct {
+		models.RecurringSchedule
+		Active *bool `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if msg := normalizeRecurringSchedule(&input.RecurringSchedule); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"description": input.Description,
+			"category":    input.Category,
+			"amount":      input.Amount,
+			"type":        input.Type,
+			"frequency":   input.Frequency,
+			"interval":    input.Interval,
+			"next_date":   input.NextDate,
+			"updated_at":  time.Now(),
+		},
+	}
+	if input.Active != nil {
+		update["$set"].(bson.M)["active"] = *input.Active
+	}
+
+	result, err := db.Client.Database("fintrack").Collection("recurring").UpdateOne(ctx, bson.M{"_id": id, "user_id": userObjectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recurring schedule"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring schedule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring schedule updated"})
+}
+
+// DeleteRecurringSchedule removes a recurring schedule
+func DeleteRecurringSchedule(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	result, err := db.Client.Database("fintrack").Collection("recurring").DeleteOne(ctx, bson.M{"_id": id, "user_id": userObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recurring schedule"})
+		return
+	}
+
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring schedule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring schedule deleted"})
+}
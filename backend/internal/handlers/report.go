@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+// reportPeriods resolves ?from=&to= (see parseDashboardDate) into the reported period
+// and its comparison periods. Without from/to the current calendar month is reported.
+func reportPeriods(from, to string, loc *time.Location, now time.Time) (models.ReportPeriods, error) {
+	local := now.In(loc)
+	start := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+	current := models.ReportPeriod{From: start, To: start.AddDate(0, 1, 0)}
+
+	if from != "" || to != "" {
+		rng, err := parseDashboardRange(from, to, "", loc, now)
+		if err != nil {
+			return models.ReportPeriods{}, err
+		}
+		current = models.ReportPeriod{From: rng.From, To: rng.To}
+	}
+
+	var previous models.ReportPeriod
+	if months, ok := wholeMonths(current, loc); ok {
+		// Calendar-aligned periods compare against the preceding calendar months
+		previous = models.ReportPeriod{From: current.From.AddDate(0, -months, 0), To: current.From}
+	} else {
+		length := current.To.Sub(current.From)
+		previous = models.ReportPeriod{From: current.From.Add(-length), To: current.From}
+	}
+
+	return models.ReportPeriods{
+		Current:  current,
+		Previous: previous,
+		LastYear: models.ReportPeriod{From: yearEarlier(current.From, loc), To: yearEarlier(current.To, loc)},
+	}, nil
+}
+
+// yearEarlier returns t one year back in loc, moving Feb 29 to Feb 28 rather than
+// letting AddDate roll it over into March
+func yearEarlier(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	day := local.Day()
+	if last := time.Date(local.Year()-1, local.Month()+1, 0, 0, 0, 0, 0, loc).Day(); day > last {
+		day = last
+	}
+	return time.Date(local.Year()-1, local.Month(), day, local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), loc)
+}
+
+// wholeMonths reports how many calendar months p spans when it starts and ends on the
+// first of a month in loc
+func wholeMonths(p models.ReportPeriod, loc *time.Location) (int, bool) {
+	from, to := p.From.In(loc), p.To.In(loc)
+	isMonthStart := func(t time.Time) bool {
+		return t.Day() == 1 && t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+	}
+	if !isMonthStart(from) || !isMonthStart(to) {
+		return 0, false
+	}
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	return months, months > 0
+}
+
+// categoryTotals sums income and expenses per category in the period. Expenses are
+// returned as positive amounts, so refunds booked as positive expenses reduce them.
+func categoryTotals(ctx context.Context, userID primitive.ObjectID, p models.ReportPeriod) (map[string]float64, map[string]float64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "user_id", Value: userID},
+			{Key: "type", Value: bson.D{{Key: "$in", Value: bson.A{"income", "expense"}}}},
+			{Key: "date", Value: bson.D{{Key: "$gte", Value: p.From}, {Key: "$lt", Value: p.To}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "type", Value: "$type"}, {Key: "category", Value: "$category"}}},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+		}}},
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("transactions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []struct {
+		ID struct {
+			Type     string `bson:"type"`
+			Category string `bson:"category"`
+		} `bson:"_id"`
+		Total float64 `bson:"total"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, nil, err
+	}
+
+	income := make(map[string]float64)
+	expenses := make(map[string]float64)
+	for _, row := range rows {
+		if row.ID.Type == "income" {
+			income[row.ID.Category] += row.Total
+		} else {
+			expenses[row.ID.Category] -= row.Total
+		}
+	}
+	return income, expenses, nil
+}
+
+// reportSection merges per-category totals of the three periods into lines sorted by
+// the current amount, largest first, with their subtotal
+func reportSection(current, previous, lastYear map[string]float64) models.ReportSection {
+	names := make(map[string]bool)
+	for _, m := range []map[string]float64{current, previous, lastYear} {
+		for name := range m {
+			names[name] = true
+		}
+	}
+
+	section := models.ReportSection{Lines: []models.ReportLine{}}
+	for name := range names {
+		amounts := roundReportAmounts(models.ReportAmounts{
+			Current:  current[name],
+			Previous: previous[name],
+			LastYear: lastYear[name],
+		})
+		section.Lines = append(section.Lines, models.ReportLine{Name: name, ReportAmounts: amounts})
+		section.Subtotal = addReportAmounts(section.Subtotal, amounts)
+	}
+
+	sort.Slice(section.Lines, func(i, j int) bool {
+		if section.Lines[i].Current != section.Lines[j].Current {
+			return section.Lines[i].Current > section.Lines[j].Current
+		}
+		return section.Lines[i].Name < section.Lines[j].Name
+	})
+	section.Subtotal = roundReportAmounts(section.Subtotal)
+	return section
+}
+
+func addReportAmounts(a, b models.ReportAmounts) models.ReportAmounts {
+	return models.ReportAmounts{Current: a.Current + b.Current, Previous: a.Previous + b.Previous, LastYear: a.LastYear + b.LastYear}
+}
+
+func subReportAmounts(a, b models.ReportAmounts) models.ReportAmounts {
+	return models.ReportAmounts{Current: a.Current - b.Current, Previous: a.Previous - b.Previous, LastYear: a.LastYear - b.LastYear}
+}
+
+func roundReportAmounts(a models.ReportAmounts) models.ReportAmounts {
+	return models.ReportAmounts{
+		Current:  math.Round(a.Current*100) / 100,
+		Previous: math.Round(a.Previous*100) / 100,
+		LastYear: math.Round(a.LastYear*100) / 100,
+	}
+}
+
+// GetIncomeStatement reports income and expenses by category (?from=&to=), with
+// comparison columns for the previous period and the same period last year
+func GetIncomeStatement(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	loc := userLocation(ctx, userObjectID)
+	periods, err := reportPeriods(c.Query("from"), c.Query("to"), loc, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var income, expenses [3]map[string]float64
+	for i, p := range []models.ReportPeriod{periods.Current, periods.Previous, periods.LastYear} {
+		income[i], expenses[i], err = categoryTotals(ctx, userObjectID, p)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate transactions"})
+			return
+		}
+	}
+
+	statement := models.IncomeStatement{
+		Periods:  periods,
+		Timezone: loc.String(),
+		Income:   reportSection(income[0], income[1], income[2]),
+		Expenses: reportSection(expenses[0], expenses[1], expenses[2]),
+	}
+	statement.NetIncome = roundReportAmounts(subReportAmounts(statement.Income.Subtotal, statement.Expenses.Subtotal))
+
+	c.JSON(http.StatusOK, statement)
+}
+
+// accountFlow holds the money movement of one account in one period
+type accountFlow struct {
+	Inflow, Outflow, TransferIn, TransferOut float64
+}
+
+// accountFlows sums inflows, outflows and transfers per account in the period.
+// Transactions without an account are keyed by primitive.NilObjectID.
+func accountFlows(ctx context.Context, userID primitive.ObjectID, p models.ReportPeriod) (map[primitive.ObjectID]accountFlow, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "user_id", Value: userID},
+			{Key: "date", Value: bson.D{{Key: "$gte", Value: p.From}, {Key: "$lt", Value: p.To}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "account", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$account_id", primitive.NilObjectID}}}},
+				{Key: "transfer", Value: bson.D{{Key: "$eq", Value: bson.A{"$type", "transfer"}}}},
+			}},
+			{Key: "in", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$gt", Value: bson.A{"$amount", 0}}}, "$amount", 0}}}}}},
+			{Key: "out", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$lt", Value: bson.A{"$amount", 0}}}, "$amount", 0}}}}}},
+		}}},
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("transactions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		ID struct {
+			Account  primitive.ObjectID `bson:"account"`
+			Transfer bool               `bson:"transfer"`
+		} `bson:"_id"`
+		In  float64 `bson:"in"`
+		Out float64 `bson:"out"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	flows := make(map[primitive.ObjectID]accountFlow)
+	for _, row := range rows {
+		f := flows[row.ID.Account]
+		if row.ID.Transfer {
+			f.TransferIn += row.In
+			f.TransferOut -= row.Out
+		} else {
+			f.Inflow += row.In
+			f.Outflow -= row.Out
+		}
+		flows[row.ID.Account] = f
+	}
+	return flows, nil
+}
+
+// balancesBefore returns each account's transaction total before t, keyed like accountFlows
+func balancesBefore(ctx context.Context, userID primitive.ObjectID, t time.Time) (map[primitive.ObjectID]float64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "user_id", Value: userID},
+			{Key: "date", Value: bson.D{{Key: "$lt", Value: t}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$account_id", primitive.NilObjectID}}}},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+		}}},
+	}
+
+	cursor, err := db.Client.Database("fintrack").Collection("transactions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Account primitive.ObjectID `bson:"_id"`
+		Total   float64            `bson:"total"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	totals := make(map[primitive.ObjectID]float64)
+	for _, row := range rows {
+		totals[row.Account] = row.Total
+	}
+	return totals, nil
+}
+
+// GetCashFlowReport reports inflows, outflows and transfers per account (?from=&to=),
+// with opening and closing balances and the same comparison columns as the income statement
+func GetCashFlowReport(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	loc := userLocation(ctx, userObjectID)
+	periods, err := reportPeriods(c.Query("from"), c.Query("to"), loc, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accounts, _, err := accountBalances(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	var flows [3]map[primitive.ObjectID]accountFlow
+	for i, p := range []models.ReportPeriod{periods.Current, periods.Previous, periods.LastYear} {
+		if flows[i], err = accountFlows(ctx, userObjectID, p); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate transactions"})
+			return
+		}
+	}
+
+	before, err := balancesBefore(ctx, userObjectID, periods.Current.From)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate transactions"})
+		return
+	}
+
+	row := func(key primitive.ObjectID, name string, opening float64) models.AccountCashFlow {
+		amounts := func(pick func(accountFlow) float64) models.ReportAmounts {
+			return models.ReportAmounts{
+				Current:  pick(flows[0][key]),
+				Previous: pick(flows[1][key]),
+				LastYear: pick(flows[2][key]),
+			}
+		}
+		cf := models.AccountCashFlow{
+			Name:           name,
+			OpeningBalance: opening,
+			Inflows:        amounts(func(f accountFlow) float64 { return f.Inflow }),
+			Outflows:       amounts(func(f accountFlow) float64 { return f.Outflow }),
+			TransfersIn:    amounts(func(f accountFlow) float64 { return f.TransferIn }),
+			TransfersOut:   amounts(func(f accountFlow) float64 { return f.TransferOut }),
+		}
+		cf.NetChange = subReportAmounts(addReportAmounts(cf.Inflows, cf.TransfersIn), addReportAmounts(cf.Outflows, cf.TransfersOut))
+		cf.ClosingBalance = opening + cf.NetChange.Current
+		return cf
+	}
+
+	report := models.CashFlowReport{
+		Periods:  periods,
+		Timezone: loc.String(),
+		Accounts: []models.AccountCashFlow{},
+		Total:    models.AccountCashFlow{Name: "Total"},
+	}
+
+	rows := make([]models.AccountCashFlow, 0, len(accounts)+1)
+	for _, a := range accounts {
+		cf := row(a.ID, a.Name, a.OpeningBalance+before[a.ID])
+		id := a.ID
+		cf.AccountID = &id
+		rows = append(rows, cf)
+	}
+	// Transactions not booked against an account are reported on their own row
+	unassigned := row(primitive.NilObjectID, "Unassigned", before[primitive.NilObjectID])
+	_, hasBefore := before[primitive.NilObjectID]
+	_, hasCurrent := flows[0][primitive.NilObjectID]
+	_, hasPrevious := flows[1][primitive.NilObjectID]
+	_, hasLastYear := flows[2][primitive.NilObjectID]
+	if hasBefore || hasCurrent || hasPrevious || hasLastYear {
+		rows = append(rows, unassigned)
+	}
+
+	for _, cf := range rows {
+		report.Total.OpeningBalance += cf.OpeningBalance
+		report.Total.Inflows = addReportAmounts(report.Total.Inflows, cf.Inflows)
+		report.Total.Outflows = addReportAmounts(report.Total.Outflows, cf.Outflows)
+		report.Total.TransfersIn = addReportAmounts(report.Total.TransfersIn, cf.TransfersIn)
+		report.Total.TransfersOut = addReportAmounts(report.Total.TransfersOut, cf.TransfersOut)
+		report.Total.NetChange = addReportAmounts(report.Total.NetChange, cf.NetChange)
+		report.Total.ClosingBalance += cf.ClosingBalance
+		report.Accounts = append(report.Accounts, roundCashFlow(cf))
+	}
+	report.Total = roundCashFlow(report.Total)
+
+	c.JSON(http.StatusOK, report)
+}
+
+func roundCashFlow(cf models.AccountCashFlow) models.AccountCashFlow {
+	cf.OpeningBalance = math.Round(cf.OpeningBalance*100) / 100
+	cf.Inflows = roundReportAmounts(cf.Inflows)
+	cf.Outflows = roundReportAmounts(cf.Outflows)
+	cf.TransfersIn = roundReportAmounts(cf.TransfersIn)
+	cf.TransfersOut = roundReportAmounts(cf.TransfersOut)
+	cf.NetChange = roundReportAmounts(cf.NetChange)
+	cf.ClosingBalance = math.Round(cf.ClosingBalance*100) / 100
+	return cf
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"fintrack-backend/internal/models"
+)
+
+func TestReportPeriods(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, loc)
+	}
+	period := func(from, to time.Time) models.ReportPeriod {
+		return models.ReportPeriod{From: from, To: to}
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		now      time.Time
+		want     models.ReportPeriods
+	}{
+		{
+			name: "default is the current month",
+			now:  time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC),
+			want: models.ReportPeriods{
+				Current:  period(day(2024, 6, 1), day(2024, 7, 1)),
+				Previous: period(day(2024, 5, 1), day(2024, 6, 1)),
+				LastYear: period(day(2023, 6, 1), day(2023, 7, 1)),
+			},
+		},
+		{
+			// Still May in UTC, already June in Berlin
+			name: "default month follows the user's zone",
+			now:  time.Date(2024, 5, 31, 23, 30, 0, 0, time.UTC),
+			want: models.ReportPeriods{
+				Current:  period(day(2024, 6, 1), day(2024, 7, 1)),
+				Previous: period(day(2024, 5, 1), day(2024, 6, 1)),
+				LastYear: period(day(2023, 6, 1), day(2023, 7, 1)),
+			},
+		},
+		{
+			name: "calendar months compare against the preceding months",
+			from: "2024-01-01", to: "2024-03-31",
+			now: time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC),
+			want: models.ReportPeriods{
+				Current:  period(day(2024, 1, 1), day(2024, 4, 1)),
+				Previous: period(day(2023, 10, 1), day(2024, 1, 1)),
+				LastYear: period(day(2023, 1, 1), day(2023, 4, 1)),
+			},
+		},
+		{
+			name: "day ranges compare against the same length before",
+			from: "2024-03-10", to: "2024-03-16",
+			now: time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC),
+			want: models.ReportPeriods{
+				Current:  period(day(2024, 3, 10), day(2024, 3, 17)),
+				Previous: period(day(2024, 3, 3), day(2024, 3, 10)),
+				LastYear: period(day(2023, 3, 10), day(2023, 3, 17)),
+			},
+		},
+		{
+			name: "February of a leap year",
+			from: "2024-02-01", to: "2024-02-29",
+			now: time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC),
+			want: models.ReportPeriods{
+				Current:  period(day(2024, 2, 1), day(2024, 3, 1)),
+				Previous: period(day(2024, 1, 1), day(2024, 2, 1)),
+				LastYear: period(day(2023, 2, 1), day(2023, 3, 1)),
+			},
+		},
+		{
+			name: "leap day maps to Feb 28 a year earlier",
+			from: "2024-02-29", to: "2024-02-29",
+			now: time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC),
+			want: models.ReportPeriods{
+				Current:  period(day(2024, 2, 29), day(2024, 3, 1)),
+				Previous: period(day(2024, 2, 28), day(2024, 2, 29)),
+				LastYear: period(day(2023, 2, 28), day(2023, 3, 1)),
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := reportPeriods(tt.from, tt.to, loc, tt.now)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for _, p := range []struct {
+			label     string
+			got, want models.ReportPeriod
+		}{
+			{"current", got.Current, tt.want.Current},
+			{"previous", got.Previous, tt.want.Previous},
+			{"last year", got.LastYear, tt.want.LastYear},
+		} {
+			if !p.got.From.Equal(p.want.From) || !p.got.To.Equal(p.want.To) {
+				t.Errorf("%s: %s = %v - %v, want %v - %v", tt.name, p.label, p.got.From, p.got.To, p.want.From, p.want.To)
+			}
+		}
+	}
+
+	if _, err := reportPeriods("2024-03-31", "2024-03-01", loc, time.Now()); err == nil {
+		t.Error("reportPeriods accepted from after to")
+	}
+}
+
+func TestWholeMonths(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	tests := []struct {
+		name     string
+		from, to time.Time
+		months   int
+		ok       bool
+	}{
+		{"one month", time.Date(2024, 2, 1, 0, 0, 0, 0, loc), time.Date(2024, 3, 1, 0, 0, 0, 0, loc), 1, true},
+		{"across a year", time.Date(2023, 11, 1, 0, 0, 0, 0, loc), time.Date(2024, 2, 1, 0, 0, 0, 0, loc), 3, true},
+		{"mid-month start", time.Date(2024, 2, 2, 0, 0, 0, 0, loc), time.Date(2024, 3, 1, 0, 0, 0, 0, loc), 0, false},
+		{"not at midnight", time.Date(2024, 2, 1, 12, 0, 0, 0, loc), time.Date(2024, 3, 1, 0, 0, 0, 0, loc), 0, false},
+		// Midnight UTC is 01:00 in Berlin, so it is not a month start there
+		{"month start in another zone", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 0, false},
+		{"empty", time.Date(2024, 2, 1, 0, 0, 0, 0, loc), time.Date(2024, 2, 1, 0, 0, 0, 0, loc), 0, false},
+	}
+	for _, tt := range tests {
+		months, ok := wholeMonths(models.ReportPeriod{From: tt.from, To: tt.to}, loc)
+		if months != tt.months || ok != tt.ok {
+			t.Errorf("%s: wholeMonths = %d, %v, want %d, %v", tt.name, months, ok, tt.months, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/auth"
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+// startSession records a new session for the device making the request and issues
+// its first tokens. It is called on every successful login.
+func startSession(ctx context.Context, c *gin.Context, userID primitive.ObjectID) (*models.TokenPair, error) {
+	now := time.Now()
+	session := models.Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     userID,
+		UserAgent:  c.Request.UserAgent(),
+		IP:         c.ClientIP(),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(auth.RefreshTokenTTL),
+	}
+	if _, err := db.Client.Database("fintrack").Collection("sessions").InsertOne(ctx, session); err != nil {
+		return nil, err
+	}
+	return issueTokens(ctx, userID, session.ID)
+}
+
+// revokeSession ends a session: its access tokens are rejected from now on and
+// its refresh tokens can no longer be used
+func revokeSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	now := time.Now()
+	_, err := db.Client.Database("fintrack").Collection("sessions").UpdateOne(ctx,
+		bson.M{"_id": sessionID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now}})
+	if err != nil {
+		return err
+	}
+	_, err = db.Client.Database("fintrack").Collection("refresh_tokens").UpdateMany(ctx,
+		bson.M{"session_id": sessionID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now}})
+	return err
+}
+
+// revokeUserSessions ends every session of a user
+func revokeUserSessions(ctx context.Context, userID primitive.ObjectID) error {
+	now := time.Now()
+	_, err := db.Client.Database("fintrack").Collection("sessions").UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now}})
+	if err != nil {
+		return err
+	}
+	_, err = db.Client.Database("fintrack").Collection("refresh_tokens").UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now}})
+	return err
+}
+
+// GetSessions lists the user's active sessions, most recently used first
+func GetSessions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "last_used_at", Value: -1}})
+
+	cursor, err := db.Client.Database("fintrack").Collection("sessions").Find(ctx, bson.M{
+		"user_id":    userObjectID,
+		"revoked_at": nil,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	sessions := []models.Session{}
+	if err = cursor.All(ctx, &sessions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse sessions"})
+		return
+	}
+
+	current := c.GetString("sessionID")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.Hex() == current
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession logs out one of the user's sessions, e.g. a lost device
+func RevokeSession(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	count, err := db.Client.Database("fintrack").Collection("sessions").CountDocuments(ctx,
+		bson.M{"_id": id, "user_id": userObjectID, "revoked_at": nil})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := revokeSession(ctx, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
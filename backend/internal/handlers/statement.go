@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/email"
+	"fintrack-backend/internal/mailer"
+	"fintrack-backend/internal/models"
+	"fintrack-backend/internal/notify"
+	"fintrack-backend/internal/statement"
+)
+
+// Number of largest expenses listed on a statement
+const statementLargestTransactions = 5
+
+// buildMonthlyStatement gathers the statement data for the calendar month starting at
+// periodStart, which must be in the user's time zone
+func buildMonthlyStatement(ctx context.Context, user models.User, periodStart time.Time) (*models.MonthlyStatement, error) {
+	loc := periodStart.Location()
+	period := models.ReportPeriod{From: periodStart, To: periodStart.AddDate(0, 1, 0)}
+
+	income, expenses, err := categoryTotals(ctx, user.ID, period)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate transactions: %w", err)
+	}
+
+	s := &models.MonthlyStatement{
+		UserName:    user.Name,
+		Period:      periodStart.Format("2006-01"),
+		PeriodStart: period.From,
+		PeriodEnd:   period.To,
+		Timezone:    loc.String(),
+		Categories:  []models.StatementCategory{},
+		GeneratedAt: time.Now().In(loc),
+	}
+	for _, v := range income {
+		s.Income += v
+	}
+	for _, v := range expenses {
+		s.Expenses += v
+	}
+	for name, v := range expenses {
+		if v <= 0 {
+			continue
+		}
+		category := models.StatementCategory{Name: name, Amount: math.Round(v*100) / 100}
+		// Refunds can outweigh spending, leaving no total to take shares of
+		if s.Expenses > 0 {
+			category.Share = math.Round(v / s.Expenses * 100)
+		}
+		s.Categories = append(s.Categories, category)
+	}
+	sort.Slice(s.Categories, func(i, j int) bool { return s.Categories[i].Amount > s.Categories[j].Amount })
+
+	s.Income = math.Round(s.Income*100) / 100
+	s.Expenses = math.Round(s.Expenses*100) / 100
+	s.Net = math.Round((s.Income-s.Expenses)*100) / 100
+	if s.Income > 0 {
+		s.SavingsRate = math.Round(s.Net / s.Income * 100)
+	}
+
+	// Budgets as they stood at the end of the month
+	overview, err := buildBudgetOverview(ctx, user.ID, period.To.Add(-time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("budget overview: %w", err)
+	}
+	s.Budgets = overview.Categories
+
+	// Goals as they stand now; there is no point-in-time goal history
+	s.Goals, err = goalsWithProgress(ctx, user.ID, false, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("goals: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "amount", Value: 1}})
+	findOptions.SetLimit(statementLargestTransactions)
+
+	cursor, err := db.Client.Database("fintrack").Collection("transactions").Find(ctx, bson.M{
+		"user_id": user.ID,
+		"type":    "expense",
+		"date":    bson.M{"$gte": period.From, "$lt": period.To},
+	}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("fetch largest transactions: %w", err)
+	}
+	if err = cursor.All(ctx, &s.LargestTransactions); err != nil {
+		return nil, fmt.Errorf("decode largest transactions: %w", err)
+	}
+	for i := range s.LargestTransactions {
+		s.LargestTransactions[i].Date = s.LargestTransactions[i].Date.In(loc)
+	}
+
+	return s, nil
+}
+
+// renderStatement builds and renders the user's statement for the month starting at
+// periodStart, returning the rendered documents and the data they were built from
+func renderStatement(ctx context.Context, user models.User, periodStart time.Time) (*models.StoredStatement, *models.MonthlyStatement, error) {
+	data, err := buildMonthlyStatement(ctx, user, periodStart)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	html, err := statement.RenderHTML(*data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("render statement: %w", err)
+	}
+
+	return &models.StoredStatement{
+		UserID:      user.ID,
+		Period:      data.Period,
+		HTML:        html,
+		PDF:         statement.RenderPDF(*data),
+		GeneratedAt: data.GeneratedAt,
+	}, data, nil
+}
+
+// How long filing one user's statement may take, including rendering and email
+const statementUserTimeout = time.Minute
+
+// GenerateMonthlyStatements files last month's statement for every user. It is run
+// on the first of each month by the scheduler. Only loading the user list is bound
+// to ctx; each user then gets their own deadline, so one slow user or a large user
+// base does not leave everyone after them without a statement.
+func GenerateMonthlyStatements(ctx context.Context) error {
+	now := time.Now().UTC()
+	lastMonth := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+
+	cursor, err := db.GetCollection("users").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	var users []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &users); err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		fileMonthlyStatement(u.ID, lastMonth)
+	}
+	return nil
+}
+
+// fileMonthlyStatement renders, stores and delivers one user's statement for month
+func fileMonthlyStatement(userID primitive.ObjectID, month time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), statementUserTimeout)
+	defer cancel()
+
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Statement for user %s failed: %v", userID.Hex(), err)
+		return
+	}
+
+	// The month is the same for everyone, its boundaries follow the user's time zone
+	periodStart := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, timezoneLocation(user.Timezone))
+	stored, data, err := renderStatement(ctx, user, periodStart)
+	if err != nil {
+		log.Printf("Statement for user %s failed: %v", user.ID.Hex(), err)
+		return
+	}
+
+	_, err = db.Client.Database("fintrack").Collection("statements").UpdateOne(ctx,
+		bson.M{"user_id": user.ID, "period": stored.Period},
+		bson.M{"$set": bson.M{"html": stored.HTML, "pdf": stored.PDF, "generated_at": stored.GeneratedAt}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		log.Printf("Statement for user %s could not be saved: %v", user.ID.Hex(), err)
+		return
+	}
+
+	notify.DispatchAsync(notify.Message{
+		UserID: user.ID,
+		Type:   "statement_ready",
+		Title:  fmt.Sprintf("Your %s statement is ready", periodStart.Format("January 2006")),
+		Body:   "Your monthly statement has been filed and can be downloaded from FinTrack.",
+		Data:   map[string]interface{}{"period": stored.Period},
+	})
+
+	attachment := mailer.Attachment{
+		Filename:    fmt.Sprintf("fintrack-statement-%s.pdf", stored.Period),
+		ContentType: "application/pdf",
+		Data:        stored.PDF,
+	}
+	if err := email.Send(ctx, user, models.EmailListMonthlyStatements, "monthly_statement", data, attachment); err != nil {
+		log.Printf("Statement for user %s could not be emailed: %v", user.ID.Hex(), err)
+	}
+}
+
+// GetStatements lists the statements filed for the user, newest first
+func GetStatements(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "period", Value: -1}})
+	findOptions.SetProjection(bson.M{"html": 0, "pdf": 0})
+
+	cursor, err := db.Client.Database("fintrack").Collection("statements").Find(ctx, bson.M{"user_id": userObjectID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statements"})
+		return
+	}
+
+	statements := []models.StoredStatement{}
+	if err = cursor.All(ctx, &statements); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse statements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, statements)
+}
+
+// DownloadStatement returns the statement for :period (YYYY-MM) as ?format=pdf (default)
+// or html. The filed copy is served when there is one; otherwise, or with
+// ?refresh=true, the statement is rendered from current data.
+func DownloadStatement(c *gin.Context) {
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "html" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be pdf or html"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": userObjectID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	periodStart, err := time.ParseInLocation("2006-01", c.Param("period"), timezoneLocation(user.Timezone))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Period must be in YYYY-MM format"})
+		return
+	}
+
+	var stored models.StoredStatement
+	err = db.Client.Database("fintrack").Collection("statements").FindOne(ctx,
+		bson.M{"user_id": userObjectID, "period": c.Param("period")}).Decode(&stored)
+	if err == mongo.ErrNoDocuments || c.Query("refresh") == "true" {
+		rendered, _, renderErr := renderStatement(ctx, user, periodStart)
+		if renderErr != nil {
+			log.Printf("Statement for user %s failed: %v", userObjectID.Hex(), renderErr)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate statement"})
+			return
+		}
+		stored = *rendered
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statement"})
+		return
+	}
+
+	filename := fmt.Sprintf("fintrack-statement-%s.%s", stored.Period, format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == "html" {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", stored.HTML)
+		return
+	}
+	c.Data(http.StatusOK, "application/pdf", stored.PDF)
+}
@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+	"fintrack-backend/internal/notify"
+)
+
+const (
+	// Transaction history analyzed for periodic charges
+	subscriptionHistoryDays = 400
+	// Charges deviating more than this from the median amount are not part of the series
+	subscriptionAmountTolerance = 0.35
+	// Share of intervals that must match the cadence
+	subscriptionMinRegularity = 0.75
+	// Smallest relative amount change reported as a price change
+	subscriptionPriceChangeThreshold = 0.01
+)
+
+// subscriptionCadence is a billing rhythm expressed in RecurringSchedule terms
+type subscriptionCadence struct {
+	Frequency string
+	Interval  int
+	Days      float64
+	Tolerance float64 // Allowed deviation of a single interval, in days
+}
+
+var subscriptionCadences = []subscriptionCadence{
+	{Frequency: "weekly", Interval: 1, Days: 7, Tolerance: 1.5},
+	{Frequency: "weekly", Interval: 2, Days: 14, Tolerance: 2},
+	{Frequency: "monthly", Interval: 1, Days: daysPerMonth, Tolerance: 4},
+	{Frequency: "monthly", Interval: 3, Days: 3 * daysPerMonth, Tolerance: 7},
+	{Frequency: "monthly", Interval: 6, Days: 6 * daysPerMonth, Tolerance: 10},
+	{Frequency: "yearly", Interval: 1, Days: 365.25, Tolerance: 15},
+}
+
+var (
+	merchantNonLetters = regexp.MustCompile(`[^a-z]+`)
+	// Words that vary between statements of the same payee
+	merchantNoiseWords = map[string]bool{
+		"com": true, "www": true, "inc": true, "ltd": true, "llc": true, "gmbh": true,
+		"payment": true, "subscription": true, "bill": true, "purchase": true,
+		"pos": true, "debit": true, "card": true, "recurring": true,
+	}
+)
+
+// normalizeMerchant reduces a transaction description to a payee key, e.g.
+// "NETFLIX.COM 0412" and "Netflix subscription" both become "netflix"
+func normalizeMerchant(description string) string {
+	var words []string
+	for _, w := range strings.Fields(merchantNonLetters.ReplaceAllString(strings.ToLower(description), " ")) {
+		if len(w) > 1 && !merchantNoiseWords[w] {
+			words = append(words, w)
+		}
+		if len(words) == 2 {
+			break
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// detectSubscription checks whether the charges of one payee (oldest first) form a
+// periodic series and describes it. Series need at least three charges, or two for
+// yearly billing.
+func detectSubscription(charges []models.Transaction, now time.Time) (*models.Subscription, bool) {
+	amounts := make([]float64, len(charges))
+	for i, t := range charges {
+		amounts[i] = math.Abs(t.Amount)
+	}
+	typical := median(amounts)
+	if typical == 0 {
+		return nil, false
+	}
+
+	var series []models.Transaction
+	for _, t := range charges {
+		if math.Abs(math.Abs(t.Amount)-typical)/typical <= subscriptionAmountTolerance {
+			series = append(series, t)
+		}
+	}
+	if len(series) < 2 {
+		return nil, false
+	}
+
+	intervals := make([]float64, 0, len(series)-1)
+	for i := 1; i < len(series); i++ {
+		intervals = append(intervals, series[i].Date.Sub(series[i-1].Date).Hours()/24)
+	}
+	typicalInterval := median(intervals)
+
+	var cadence *subscriptionCadence
+	for i := range subscriptionCadences {
+		if math.Abs(typicalInterval-subscriptionCadences[i].Days) <= subscriptionCadences[i].Tolerance {
+			cadence = &subscriptionCadences[i]
+			break
+		}
+	}
+	if cadence == nil || (len(series) < 3 && cadence.Frequency != "yearly") {
+		return nil, false
+	}
+
+	regular := 0
+	for _, d := range intervals {
+		if math.Abs(d-cadence.Days) <= cadence.Tolerance {
+			regular++
+		}
+	}
+	if float64(regular)/float64(len(intervals)) < subscriptionMinRegularity {
+		return nil, false
+	}
+
+	last := series[len(series)-1]
+	amount := math.Abs(last.Amount)
+	next := models.AdvanceDate(last.Date, cadence.Frequency, cadence.Interval)
+	grace := time.Duration(cadence.Days / 2 * 24 * float64(time.Hour))
+
+	sub := &models.Subscription{
+		Name:        last.Description,
+		Category:    last.Category,
+		Amount:      amount,
+		Frequency:   cadence.Frequency,
+		Interval:    cadence.Interval,
+		Occurrences: len(series),
+		LastDate:    last.Date,
+		NextDate:    next,
+		AnnualCost:  math.Round(amount*365.25/cadence.Days*100) / 100,
+		Active:      now.Before(next.Add(grace)),
+	}
+
+	// The latest price change is where the amount last differed from the current one
+	for i := len(series) - 2; i >= 0; i-- {
+		previous := math.Abs(series[i].Amount)
+		if math.Abs(previous-amount)/previous > subscriptionPriceChangeThreshold {
+			sub.PriceChange = &models.SubscriptionPriceChange{
+				PreviousAmount: previous,
+				NewAmount:      amount,
+				Percent:        math.Round((amount-previous)/previous*1000) / 10,
+				Date:           series[i+1].Date,
+			}
+			break
+		}
+	}
+
+	return sub, true
+}
+
+// refreshSubscriptions re-runs detection over the user's expense history, updates the
+// stored subscriptions and alerts about price changes on the latest charge
+func refreshSubscriptions(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "date", Value: 1}})
+
+	cursor, err := db.Client.Database("fintrack").Collection("transactions").Find(ctx, bson.M{
+		"user_id": userID,
+		"type":    "expense",
+		"date":    bson.M{"$gte": now.AddDate(0, 0, -subscriptionHistoryDays), "$lte": now},
+	}, findOptions)
+	if err != nil {
+		return err
+	}
+
+	var transactions []models.Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return err
+	}
+
+	byMerchant := make(map[string][]models.Transaction)
+	for _, t := range transactions {
+		if key := normalizeMerchant(t.Description); key != "" {
+			byMerchant[key] = append(byMerchant[key], t)
+		}
+	}
+
+	// Payees the user already tracks as a schedule are confirmed from the start
+	schedules, err := loadRecurringSchedules(ctx, userID, "expense")
+	if err != nil {
+		return err
+	}
+	scheduled := make(map[string]primitive.ObjectID)
+	for _, s := range schedules {
+		scheduled[normalizeMerchant(s.Description)] = s.ID
+	}
+
+	collection := db.Client.Database("fintrack").Collection("subscriptions")
+	for merchant, charges := range byMerchant {
+		detected, ok := detectSubscription(charges, now)
+		if !ok {
+			continue
+		}
+
+		onInsert := bson.M{"status": models.SubscriptionDetected, "detected_at": now}
+		if scheduleID, ok := scheduled[merchant]; ok {
+			onInsert["status"] = models.SubscriptionConfirmed
+			onInsert["schedule_id"] = scheduleID
+		}
+
+		var sub models.Subscription
+		err := collection.FindOneAndUpdate(ctx,
+			bson.M{"user_id": userID, "merchant": merchant},
+			bson.M{
+				"$set": bson.M{
+					"name":        detected.Name,
+					"category":    detected.Category,
+					"amount":      detected.Amount,
+					"frequency":   detected.Frequency,
+					"interval":    detected.Interval,
+					"occurrences": detected.Occurrences,
+					"last_date":   detected.LastDate,
+					"next_date":   detected.NextDate,
+					"annual_cost": detected.AnnualCost,
+					"active":      detected.Active,
+					"updated_at":  now,
+				},
+				"$setOnInsert": onInsert,
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&sub)
+		if err != nil {
+			return err
+		}
+
+		if detected.PriceChange == nil {
+			continue
+		}
+
+		// Claim the price change so that it is alerted only once
+		result, err := collection.UpdateOne(ctx,
+			bson.M{"_id": sub.ID, "price_change.date": bson.M{"$ne": detected.PriceChange.Date}},
+			bson.M{"$set": bson.M{"price_change": detected.PriceChange}})
+		if err != nil {
+			return err
+		}
+		// Only a change on the latest charge is news; older ones were already paid several times
+		if result.ModifiedCount > 0 && sub.Status != models.SubscriptionDismissed && detected.PriceChange.Date.Equal(detected.LastDate) {
+			notify.DispatchAsync(subscriptionPriceChangeMessage(sub, *detected.PriceChange))
+		}
+	}
+	return nil
+}
+
+func subscriptionPriceChangeMessage(sub models.Subscription, change models.SubscriptionPriceChange) notify.Message {
+	direction := "increased"
+	if change.NewAmount < change.PreviousAmount {
+		direction = "decreased"
+	}
+
+	return notify.Message{
+		UserID: sub.UserID,
+		Type:   "subscription_price_change",
+		Title:  fmt.Sprintf("%s price %s", sub.Name, direction),
+		Body: fmt.Sprintf("%s %s from %.2f to %.2f (%+.1f%%). That is %.2f per year.",
+			sub.Name, direction, change.PreviousAmount, change.NewAmount, change.Percent, sub.AnnualCost),
+		Data: map[string]interface{}{
+			"subscription_id": sub.ID.Hex(),
+			"previous_amount": change.PreviousAmount,
+			"new_amount":      change.NewAmount,
+			"percent":         change.Percent,
+		},
+	}
+}
+
+// RunSubscriptionDetection refreshes detected subscriptions for every user. It is run
+// daily by the scheduler.
+func RunSubscriptionDetection(ctx context.Context) error {
+	cursor, err := db.GetCollection("users").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now()
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		if err := refreshSubscriptions(ctx, user.ID, now); err != nil {
+			log.Printf("Subscription detection for user %s failed: %v", user.ID.Hex(), err)
+		}
+	}
+	return cursor.Err()
+}
+
+// GetSubscriptions detects and lists periodic charges, most expensive first.
+// Dismissed ones are only included with ?include_dismissed=true.
+func GetSubscriptions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	if err := refreshSubscriptions(ctx, userObjectID, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to analyze transactions"})
+		return
+	}
+
+	filter := bson.M{"user_id": userObjectID}
+	if c.Query("include_dismissed") != "true" {
+		filter["status"] = bson.M{"$ne": models.SubscriptionDismissed}
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "active", Value: -1}, {Key: "annual_cost", Value: -1}})
+
+	cursor, err := db.Client.Database("fintrack").Collection("subscriptions").Find(ctx, filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscriptions"})
+		return
+	}
+
+	subscriptions := []models.Subscription{}
+	if err = cursor.All(ctx, &subscriptions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse subscriptions"})
+		return
+	}
+
+	annualTotal := 0.0
+	for _, s := range subscriptions {
+		if s.Active {
+			annualTotal += s.AnnualCost
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"subscriptions": subscriptions,
+		"annual_total":  math.Round(annualTotal*100) / 100,
+	})
+}
+
+// ConfirmSubscription turns a detected subscription into a recurring schedule
+func ConfirmSubscription(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := db.Client.Database("fintrack").Collection("subscriptions")
+
+	var sub models.Subscription
+	if err := collection.FindOne(ctx, bson.M{"_id": id, "user_id": userObjectID}).Decode(&sub); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	}
+	if sub.Status == models.SubscriptionConfirmed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Subscription is already confirmed"})
+		return
+	}
+
+	schedule := models.RecurringSchedule{
+		ID:          primitive.NewObjectID(),
+		UserID:      userObjectID,
+		Description: sub.Name,
+		Category:    sub.Category,
+		Amount:      -sub.Amount,
+		Type:        "expense",
+		Frequency:   sub.Frequency,
+		Interval:    sub.Interval,
+		NextDate:    sub.NextDate,
+		Active:      true,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if msg := normalizeRecurringSchedule(&schedule); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// Claim the confirmation first so that concurrent requests create one schedule
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": bson.M{"$ne": models.SubscriptionConfirmed}},
+		bson.M{"$set": bson.M{"status": models.SubscriptionConfirmed, "schedule_id": schedule.ID, "updated_at": time.Now()}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm subscription"})
+		return
+	}
+	if result.ModifiedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Subscription is already confirmed"})
+		return
+	}
+
+	if _, err := db.Client.Database("fintrack").Collection("recurring").InsertOne(ctx, schedule); err != nil {
+		collection.UpdateOne(ctx, bson.M{"_id": id},
+			bson.M{"$set": bson.M{"status": sub.Status}, "$unset": bson.M{"schedule_id": ""}})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring schedule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// DismissSubscription marks a detection as not being a subscription
+func DismissSubscription(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Client.Database("fintrack").Collection("subscriptions").UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userObjectID},
+		bson.M{"$set": bson.M{"status": models.SubscriptionDismissed, "updated_at": time.Now()}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss subscription"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription dismissed"})
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"fintrack-backend/internal/models"
+)
+
+func TestNormalizeMerchant(t *testing.T) {
+	tests := map[string]string{
+		"NETFLIX.COM 0412":               "netflix",
+		"Netflix subscription":           "netflix",
+		"POS DEBIT Spotify AB Stockholm": "spotify ab",
+		"Amazon Prime *A12BC":            "amazon prime",
+		"www.dropbox.com inc.":           "dropbox",
+		"12345 / 678":                    "",
+		"A B C":                          "",
+	}
+	for description, want := range tests {
+		if got := normalizeMerchant(description); got != want {
+			t.Errorf("normalizeMerchant(%q) = %q, want %q", description, got, want)
+		}
+	}
+}
+
+// charges builds a payee's expenses from dates and amounts (positive)
+func charges(dates []time.Time, amounts ...float64) []models.Transaction {
+	txs := make([]models.Transaction, len(dates))
+	for i, d := range dates {
+		amount := amounts[0]
+		if i < len(amounts) {
+			amount = amounts[i]
+		}
+		txs[i] = models.Transaction{Description: "Streamly", Category: "Entertainment", Amount: -amount, Date: d, Type: "expense"}
+	}
+	return txs
+}
+
+// every returns n dates starting at start, each advanced by the given cadence
+func every(start time.Time, n int, frequency string, interval int) []time.Time {
+	dates := make([]time.Time, n)
+	for i := range dates {
+		dates[i] = start
+		start = models.AdvanceDate(start, frequency, interval)
+	}
+	return dates
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDetectSubscriptionCadences(t *testing.T) {
+	tests := []struct {
+		name        string
+		dates       []time.Time
+		amount      float64
+		now         time.Time
+		frequency   string
+		interval    int
+		next        time.Time
+		annualCost  float64
+		occurrences int
+	}{
+		{
+			name:  "monthly",
+			dates: every(day(2026, 1, 5), 4, "monthly", 1), amount: 9.99, now: day(2026, 4, 20),
+			frequency: "monthly", interval: 1, next: day(2026, 5, 5), annualCost: 119.87, occurrences: 4,
+		},
+		{
+			name:  "weekly",
+			dates: every(day(2026, 3, 2), 5, "weekly", 1), amount: 5, now: day(2026, 4, 1),
+			frequency: "weekly", interval: 1, next: day(2026, 4, 6), annualCost: 260.89, occurrences: 5,
+		},
+		{
+			name:  "every two weeks",
+			dates: every(day(2026, 1, 2), 4, "weekly", 2), amount: 5, now: day(2026, 2, 20),
+			frequency: "weekly", interval: 2, next: day(2026, 2, 27), occurrences: 4,
+		},
+		{
+			name:  "yearly with two charges",
+			dates: every(day(2025, 1, 10), 2, "yearly", 1), amount: 99, now: day(2026, 2, 1),
+			frequency: "yearly", interval: 1, next: day(2027, 1, 10), annualCost: 99, occurrences: 2,
+		},
+	}
+	for _, tt := range tests {
+		sub, ok := detectSubscription(charges(tt.dates, tt.amount), tt.now)
+		if !ok {
+			t.Errorf("%s: not detected", tt.name)
+			continue
+		}
+		if sub.Frequency != tt.frequency || sub.Interval != tt.interval {
+			t.Errorf("%s: cadence = %s/%d, want %s/%d", tt.name, sub.Frequency, sub.Interval, tt.frequency, tt.interval)
+		}
+		if !sub.NextDate.Equal(tt.next) {
+			t.Errorf("%s: next date = %v, want %v", tt.name, sub.NextDate, tt.next)
+		}
+		if tt.annualCost != 0 && sub.AnnualCost != tt.annualCost {
+			t.Errorf("%s: annual cost = %v, want %v", tt.name, sub.AnnualCost, tt.annualCost)
+		}
+		if sub.Occurrences != tt.occurrences || sub.Amount != tt.amount || !sub.Active || sub.PriceChange != nil {
+			t.Errorf("%s: subscription = %+v", tt.name, sub)
+		}
+	}
+}
+
+func TestDetectSubscriptionRejects(t *testing.T) {
+	now := day(2026, 6, 1)
+	tests := map[string][]models.Transaction{
+		"two monthly charges": charges(every(day(2026, 3, 5), 2, "monthly", 1), 10),
+		"irregular intervals": charges([]time.Time{
+			day(2026, 1, 1), day(2026, 1, 11), day(2026, 2, 25), day(2026, 3, 17), day(2026, 5, 26),
+		}, 10),
+		// Monthly on the median, but only three of five intervals are
+		"below the regularity share": charges([]time.Time{
+			day(2026, 1, 1), day(2026, 2, 1), day(2026, 3, 4), day(2026, 3, 24), day(2026, 4, 24), day(2026, 6, 8),
+		}, 10),
+		"no matching cadence": charges(every(day(2025, 1, 1), 4, "monthly", 2), 10),
+		"varying amounts":     charges(every(day(2026, 1, 5), 4, "monthly", 1), 10, 40, 90, 200),
+		"free":                charges(every(day(2026, 1, 5), 4, "monthly", 1), 0),
+		"single charge":       charges([]time.Time{day(2026, 1, 5)}, 10),
+	}
+	for name, txs := range tests {
+		if sub, ok := detectSubscription(txs, now); ok {
+			t.Errorf("%s: detected %+v", name, sub)
+		}
+	}
+}
+
+func TestDetectSubscriptionIgnoresAmountOutlier(t *testing.T) {
+	// A one-off purchase from the same payee sits between two monthly charges
+	dates := []time.Time{day(2026, 1, 5), day(2026, 2, 5), day(2026, 2, 18), day(2026, 3, 5), day(2026, 4, 5)}
+	sub, ok := detectSubscription(charges(dates, 10, 10, 60, 10, 10), day(2026, 4, 20))
+	if !ok {
+		t.Fatal("series with an outlier not detected")
+	}
+	if sub.Occurrences != 4 || sub.Amount != 10 || sub.Frequency != "monthly" || sub.PriceChange != nil {
+		t.Errorf("subscription = %+v, want 4 monthly charges of 10", sub)
+	}
+}
+
+func TestDetectSubscriptionActive(t *testing.T) {
+	txs := charges(every(day(2026, 1, 5), 4, "monthly", 1), 10)
+	// Active until half an interval after the next expected charge
+	for now, want := range map[time.Time]bool{
+		day(2026, 4, 20): true,
+		day(2026, 5, 15): true,
+		day(2026, 5, 25): false,
+		day(2026, 8, 1):  false,
+	} {
+		sub, _ := detectSubscription(txs, now)
+		if sub.Active != want {
+			t.Errorf("at %s: active = %v, want %v", now.Format("2006-01-02"), sub.Active, want)
+		}
+	}
+}
+
+func TestDetectSubscriptionPriceChange(t *testing.T) {
+	dates := every(day(2026, 1, 5), 5, "monthly", 1)
+	tests := []struct {
+		name     string
+		amounts  []float64
+		previous float64
+		percent  float64
+		date     time.Time // The first charge at the new price
+	}{
+		{"increase", []float64{10, 10, 10, 12, 12}, 10, 20, dates[3]},
+		{"decrease on the latest charge", []float64{12, 12, 12, 12, 10}, 12, -16.7, dates[4]},
+		{"latest of two changes", []float64{10, 10, 11, 11, 12}, 11, 9.1, dates[4]},
+		{"raised and lowered again", []float64{10, 12, 12, 10, 10}, 12, -16.7, dates[3]},
+	}
+	for _, tt := range tests {
+		sub, ok := detectSubscription(charges(dates, tt.amounts...), day(2026, 5, 20))
+		if !ok {
+			t.Errorf("%s: not detected", tt.name)
+			continue
+		}
+		change := sub.PriceChange
+		if change == nil {
+			t.Errorf("%s: no price change", tt.name)
+			continue
+		}
+		last := tt.amounts[len(tt.amounts)-1]
+		if change.PreviousAmount != tt.previous || change.NewAmount != last || change.Percent != tt.percent || !change.Date.Equal(tt.date) {
+			t.Errorf("%s: price change = %+v, want %v -> %v (%v%%) on %v", tt.name, change, tt.previous, last, tt.percent, tt.date)
+		}
+	}
+
+	// Changes within rounding noise are not reported
+	sub, ok := detectSubscription(charges(dates, 10, 10, 10, 10, 10.05), day(2026, 5, 20))
+	if !ok || sub.PriceChange != nil {
+		t.Errorf("0.5%% change: %+v, %v", sub, ok)
+	}
+}
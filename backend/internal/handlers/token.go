@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"fintrack-backend/internal/auth"
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+// issueTokens creates an access token and a refresh token for userID in a session
+func issueTokens(ctx context.Context, userID, sessionID primitive.ObjectID) (*models.TokenPair, error) {
+	accessToken, err := auth.GenerateToken(userID.Hex(), sessionID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = db.Client.Database("fintrack").Collection("refresh_tokens").InsertOne(ctx, models.RefreshToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		SessionID: sessionID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(auth.RefreshTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// loginResponse is the body returned after a successful login or registration
+func loginResponse(message string, user models.User, tokens *models.TokenPair) gin.H {
+	return gin.H{
+		"message":       message,
+		"user":          user,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	}
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a new
+// refresh token. The presented token cannot be used again; if it is, the session
+// is revoked because the token must have been copied.
+func RefreshSession(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hash := auth.HashToken(input.RefreshToken)
+	now := time.Now()
+
+	// Claim the token atomically so two concurrent refreshes cannot both succeed
+	var stored models.RefreshToken
+	err := db.Client.Database("fintrack").Collection("refresh_tokens").FindOneAndUpdate(ctx,
+		bson.M{"token_hash": hash, "rotated_at": nil, "revoked_at": nil, "expires_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"rotated_at": now}}).Decode(&stored)
+	if err == mongo.ErrNoDocuments {
+		// A token that was already rotated is being replayed
+		var previous models.RefreshToken
+		findErr := db.Client.Database("fintrack").Collection("refresh_tokens").FindOne(ctx, bson.M{"token_hash": hash}).Decode(&previous)
+		if findErr == nil && previous.RotatedAt != nil && previous.RevokedAt == nil {
+			log.Printf("Refresh token reuse detected for user %s, revoking session %s", previous.UserID.Hex(), previous.SessionID.Hex())
+			if err := revokeSession(ctx, previous.SessionID); err != nil {
+				log.Printf("Failed to revoke session %s: %v", previous.SessionID.Hex(), err)
+			}
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	tokens, err := issueTokens(ctx, stored.UserID, stored.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		return
+	}
+
+	_, err = db.Client.Database("fintrack").Collection("sessions").UpdateOne(ctx,
+		bson.M{"_id": stored.SessionID},
+		bson.M{"$set": bson.M{"last_used_at": now, "ip": c.ClientIP(), "expires_at": now.Add(auth.RefreshTokenTTL)}})
+	if err != nil {
+		log.Printf("Failed to update session %s: %v", stored.SessionID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the session the given refresh token belongs to. Unknown tokens
+// are ignored so that logging out is always safe to retry.
+func Logout(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var stored models.RefreshToken
+	err := db.Client.Database("fintrack").Collection("refresh_tokens").FindOne(ctx, bson.M{"token_hash": auth.HashToken(input.RefreshToken)}).Decode(&stored)
+	if err == nil {
+		if err := revokeSession(ctx, stored.SessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+	} else if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// LogoutAll revokes every session of the current user, including this one
+func LogoutAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	if err := revokeUserSessions(ctx, userObjectID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions"})
+}
@@ -14,7 +14,10 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// GetDashboardData fetches stats, recent transactions, and chart data
+// GetDashboardData fetches stats, recent transactions, and chart data.
+// ?from=&to= narrow the charts and the income/expense totals to a date range and
+// ?granularity=day|week|month controls the "series" buckets; dates are grouped in
+// the user's time zone.
 func GetDashboardData(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -26,16 +29,28 @@ func GetDashboardData(c *gin.Context) {
 	}
 	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 
+	now := time.Now()
+	rng, err := parseDashboardRange(c.Query("from"), c.Query("to"), c.Query("granularity"), userLocation(ctx, userObjectID), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	collection := db.Client.Database("fintrack").Collection("transactions")
 
 	// 1. Calculate Overall Stats (Balance, Income, Expense)
+	// Transfers between accounts are neither income nor expense and are excluded from stats.
+	// The balance is always all time; income and expense follow the requested range.
+	notTransfer := bson.D{{Key: "$ne", Value: "transfer"}}
+	inRange := rng.inRange()
+
 	statsPipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.D{{Key: "user_id", Value: userObjectID}}}},
+		{{Key: "$match", Value: bson.D{{Key: "user_id", Value: userObjectID}, {Key: "type", Value: notTransfer}}}},
 		{{Key: "$group", Value: bson.D{
 			{Key: "_id", Value: nil},
 			{Key: "totalBalance", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
-			{Key: "totalIncome", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$gt", Value: bson.A{"$amount", 0}}}, "$amount", 0}}}}}},
-			{Key: "totalExpense", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$lt", Value: bson.A{"$amount", 0}}}, "$amount", 0}}}}}},
+			{Key: "totalIncome", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$and", Value: bson.A{bson.D{{Key: "$gt", Value: bson.A{"$amount", 0}}}, inRange}}}, "$amount", 0}}}}}},
+			{Key: "totalExpense", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$and", Value: bson.A{bson.D{{Key: "$lt", Value: bson.A{"$amount", 0}}}, inRange}}}, "$amount", 0}}}}}},
 		}}},
 	}
 
@@ -75,34 +90,43 @@ func GetDashboardData(c *gin.Context) {
 		return
 	}
 
-	// 3. Monthly Stats (Last 6 Months)
-	sixMonthsAgo := time.Now().AddDate(0, -6, 0)
+	// 3. Monthly Stats (Last 6 Months unless a range is given)
 	monthlyPipeline := mongo.Pipeline{
 		{{Key: "$match", Value: bson.D{
 			{Key: "user_id", Value: userObjectID},
-			{Key: "date", Value: bson.D{{Key: "$gte", Value: sixMonthsAgo}}},
+			{Key: "date", Value: rng.dateMatch(rng.From)},
+			{Key: "type", Value: notTransfer},
 		}}},
 		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{
-				{Key: "year", Value: bson.D{{Key: "$year", Value: "$date"}}},
-				{Key: "month", Value: bson.D{{Key: "$month", Value: "$date"}}},
-			}},
+			{Key: "_id", Value: rng.dateGroupKey(granularityMonth)},
 			{Key: "income", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$gt", Value: bson.A{"$amount", 0}}}, "$amount", 0}}}}}},
 			{Key: "expense", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$lt", Value: bson.A{"$amount", 0}}}, "$amount", 0}}}}}},
 		}}},
-		{{Key: "$sort", Value: bson.D{{Key: "_id.year", Value: 1}, {Key: "_id.month", Value: 1}}}},
+		{{Key: "$sort", Value: dateGroupSort(granularityMonth)}},
 	}
 
-	cursor, _ = collection.Aggregate(ctx, monthlyPipeline)
+	cursor, err = collection.Aggregate(ctx, monthlyPipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate monthly stats"})
+		return
+	}
 	var monthlyStats []bson.M
-	cursor.All(ctx, &monthlyStats)
+	if err = cursor.All(ctx, &monthlyStats); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse monthly stats"})
+		return
+	}
 
-	// 4. Category Stats (Expenses only)
+	// 4. Category Stats (Expenses only, all time unless a range is given)
+	categoryMatch := bson.D{
+		{Key: "user_id", Value: userObjectID},
+		{Key: "amount", Value: bson.D{{Key: "$lt", Value: 0}}},
+		{Key: "type", Value: notTransfer},
+	}
+	if rng.Explicit {
+		categoryMatch = append(categoryMatch, bson.E{Key: "date", Value: rng.dateMatch(rng.From)})
+	}
 	categoryPipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.D{
-			{Key: "user_id", Value: userObjectID},
-			{Key: "amount", Value: bson.D{{Key: "$lt", Value: 0}}},
-		}}},
+		{{Key: "$match", Value: categoryMatch}},
 		{{Key: "$group", Value: bson.D{
 			{Key: "_id", Value: "$category"},
 			{Key: "value", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
@@ -110,32 +134,69 @@ func GetDashboardData(c *gin.Context) {
 		{{Key: "$sort", Value: bson.D{{Key: "value", Value: 1}}}}, // Sort by largest expense (most negative)
 	}
 
-	cursor, _ = collection.Aggregate(ctx, categoryPipeline)
+	cursor, err = collection.Aggregate(ctx, categoryPipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate category stats"})
+		return
+	}
 	var categoryStats []bson.M
-	cursor.All(ctx, &categoryStats)
+	if err = cursor.All(ctx, &categoryStats); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse category stats"})
+		return
+	}
 
-	// 5. Daily Stats (Last 7 Days)
-	sevenDaysAgo := time.Now().AddDate(0, 0, -7)
+	// 5. Daily Stats (Last 7 Days unless a range is given)
+	dailyFrom := rng.dailyFrom(now)
 	dailyPipeline := mongo.Pipeline{
 		{{Key: "$match", Value: bson.D{
 			{Key: "user_id", Value: userObjectID},
-			{Key: "date", Value: bson.D{{Key: "$gte", Value: sevenDaysAgo}}},
+			{Key: "date", Value: rng.dateMatch(dailyFrom)},
+			{Key: "type", Value: notTransfer},
 		}}},
 		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{
-				{Key: "year", Value: bson.D{{Key: "$year", Value: "$date"}}},
-				{Key: "month", Value: bson.D{{Key: "$month", Value: "$date"}}},
-				{Key: "day", Value: bson.D{{Key: "$dayOfMonth", Value: "$date"}}},
-			}},
+			{Key: "_id", Value: rng.dateGroupKey(granularityDay)},
 			{Key: "income", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$gt", Value: bson.A{"$amount", 0}}}, "$amount", 0}}}}}},
 			{Key: "expense", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$lt", Value: bson.A{"$amount", 0}}}, "$amount", 0}}}}}},
 		}}},
-		{{Key: "$sort", Value: bson.D{{Key: "_id.year", Value: 1}, {Key: "_id.month", Value: 1}, {Key: "_id.day", Value: 1}}}},
+		{{Key: "$sort", Value: dateGroupSort(granularityDay)}},
 	}
 
-	cursor, _ = collection.Aggregate(ctx, dailyPipeline)
+	cursor, err = collection.Aggregate(ctx, dailyPipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate daily stats"})
+		return
+	}
 	var dailyStats []bson.M
-	cursor.All(ctx, &dailyStats)
+	if err = cursor.All(ctx, &dailyStats); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse daily stats"})
+		return
+	}
+
+	// 6. Series over the range at the requested granularity
+	seriesPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "user_id", Value: userObjectID},
+			{Key: "date", Value: rng.dateMatch(rng.From)},
+			{Key: "type", Value: notTransfer},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: rng.dateGroupKey(rng.Granularity)},
+			{Key: "income", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$gt", Value: bson.A{"$amount", 0}}}, "$amount", 0}}}}}},
+			{Key: "expense", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$lt", Value: bson.A{"$amount", 0}}}, "$amount", 0}}}}}},
+		}}},
+		{{Key: "$sort", Value: dateGroupSort(rng.Granularity)}},
+	}
+
+	cursor, err = collection.Aggregate(ctx, seriesPipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate series"})
+		return
+	}
+	var series []bson.M
+	if err = cursor.All(ctx, &series); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse series"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"stats":         responseStats,
@@ -143,6 +204,13 @@ func GetDashboardData(c *gin.Context) {
 		"monthlyStats":  monthlyStats,
 		"categoryStats": categoryStats,
 		"dailyStats":    dailyStats,
+		"series":        series,
+		"range": gin.H{
+			"from":        rng.From,
+			"to":          rng.To,
+			"granularity": rng.Granularity,
+			"timezone":    rng.Location.String(),
+		},
 	})
 }
 
@@ -180,6 +248,11 @@ func CreateTransaction(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if transaction.AccountID != nil && !ownsAccount(ctx, transaction.UserID, *transaction.AccountID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found"})
+		return
+	}
+
 	collection := db.Client.Database("fintrack").Collection("transactions")
 	_, err := collection.InsertOne(ctx, transaction)
 	if err != nil {
@@ -187,6 +260,17 @@ func CreateTransaction(c *gin.Context) {
 		return
 	}
 
+	switch transaction.Type {
+	case "expense":
+		go checkBudgetAlerts(transaction.UserID, transaction.Category, transaction.Date)
+		go checkTransactionAnomalies(transaction)
+	case "income":
+		go applyIncomeFundingRules(transaction)
+	}
+	if transaction.AccountID != nil {
+		go checkAccountGoalMilestones(transaction.UserID)
+	}
+
 	c.JSON(http.StatusCreated, transaction)
 }
 
@@ -232,11 +316,12 @@ func UpdateTransaction(c *gin.Context) {
 	}
 
 	var updateData struct {
-		Date        time.Time `json:"date"`
-		Description string    `json:"description"`
-		Category    string    `json:"category"`
-		Amount      float64   `json:"amount"`
-		Type        string    `json:"type"`
+		Date        time.Time           `json:"date"`
+		Description string              `json:"description"`
+		Category    string              `json:"category"`
+		Amount      float64             `json:"amount"`
+		Type        string              `json:"type"`
+		AccountID   *primitive.ObjectID `json:"account_id"`
 	}
 
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -257,6 +342,37 @@ func UpdateTransaction(c *gin.Context) {
 
 	collection := db.Client.Database("fintrack").Collection("transactions")
 
+	var existing models.Transaction
+	if err := collection.FindOne(ctx, bson.M{"_id": id, "user_id": userObjectID}).Decode(&existing); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found or unauthorized"})
+		return
+	}
+
+	// Both legs of a transfer change together; moving it to other accounts or
+	// turning it into income or expense means recreating it
+	if existing.TransferID != nil {
+		if (updateData.Type != "" && updateData.Type != "transfer") ||
+			(updateData.AccountID != nil && (existing.AccountID == nil || *updateData.AccountID != *existing.AccountID)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only the date, description and amount of a transfer can be changed"})
+			return
+		}
+		if updateData.Amount == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Transfer amount must not be zero"})
+			return
+		}
+		if err := updateTransferLegs(ctx, userObjectID, *existing.TransferID, updateData.Date, updateData.Description, updateData.Amount); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+			return
+		}
+		go checkAccountGoalMilestones(userObjectID)
+		c.JSON(http.StatusOK, gin.H{"message": "Transaction updated"})
+		return
+	}
+	if updateData.Type == "transfer" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use /accounts/transfer to create a transfer"})
+		return
+	}
+
 	update := bson.M{
 		"$set": bson.M{
 			"date":        updateData.Date,
@@ -266,6 +382,13 @@ func UpdateTransaction(c *gin.Context) {
 			"type":        updateData.Type,
 		},
 	}
+	if updateData.AccountID != nil {
+		if !ownsAccount(ctx, userObjectID, *updateData.AccountID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found"})
+			return
+		}
+		update["$set"].(bson.M)["account_id"] = updateData.AccountID
+	}
 
 	result, err := collection.UpdateOne(ctx, bson.M{"_id": id, "user_id": userObjectID}, update)
 	if err != nil {
@@ -278,6 +401,13 @@ func UpdateTransaction(c *gin.Context) {
 		return
 	}
 
+	if updateData.Type == "expense" {
+		go checkBudgetAlerts(userObjectID, updateData.Category, updateData.Date)
+	}
+	if existing.AccountID != nil || updateData.AccountID != nil {
+		go checkAccountGoalMilestones(userObjectID)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Transaction updated"})
 }
 
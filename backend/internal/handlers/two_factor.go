@@ -0,0 +1,475 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"fintrack-backend/internal/auth"
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+)
+
+const (
+	// How long the second login step can be completed, and how many codes may be tried
+	loginChallengeTTL      = 5 * time.Minute
+	loginChallengeAttempts = 5
+	recoveryCodeCount      = 10
+	totpIssuer             = "FinTrack"
+	// Invalid codes accepted per user before code checks are locked, and for how long
+	twoFactorMaxFailures = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+// errTwoFactorLocked is returned by verifySecondFactor while the user is locked out
+var errTwoFactorLocked = errors.New("too many invalid two-factor codes")
+
+// completeLogin finishes a login once the password or provider check passed. Users
+// with two-factor authentication get a challenge token to exchange, together with
+// a code, at /auth/2fa/verify; everyone else gets a new session right away.
+func completeLogin(ctx context.Context, c *gin.Context, user models.User, message string) {
+	enabled, err := db.Client.Database("fintrack").Collection("two_factor").CountDocuments(ctx,
+		bson.M{"user_id": user.ID, "enabled": true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking two-factor authentication"})
+		return
+	}
+
+	if enabled > 0 {
+		token, hash, err := auth.NewOpaqueToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+			return
+		}
+		now := time.Now()
+		_, err = db.Client.Database("fintrack").Collection("login_challenges").InsertOne(ctx, models.LoginChallenge{
+			ID:        primitive.NewObjectID(),
+			UserID:    user.ID,
+			TokenHash: hash,
+			ExpiresAt: now.Add(loginChallengeTTL),
+			CreatedAt: now,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":             "Two-factor authentication required",
+			"two_factor_required": true,
+			"challenge_token":     token,
+			"expires_in":          int(loginChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	tokens, err := startSession(ctx, c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse(message, user, tokens))
+}
+
+// verifySecondFactor checks an authenticator or recovery code against the user's
+// enabled enrollment and consumes it: each TOTP step and recovery code works once.
+// Every attempt is counted before the code is checked; after twoFactorMaxFailures
+// invalid codes in a row all checks fail with errTwoFactorLocked for a while.
+func verifySecondFactor(ctx context.Context, userID primitive.ObjectID, code string) (bool, error) {
+	collection := db.Client.Database("fintrack").Collection("two_factor")
+
+	now := time.Now()
+	var tf models.TwoFactor
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"user_id": userID,
+			"enabled": true,
+			"$or":     bson.A{bson.M{"locked_until": nil}, bson.M{"locked_until": bson.M{"$lte": now}}},
+		},
+		bson.M{"$inc": bson.M{"failed_attempts": 1}, "$unset": bson.M{"locked_until": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&tf)
+	if err == mongo.ErrNoDocuments {
+		locked, err := collection.CountDocuments(ctx, bson.M{"user_id": userID, "enabled": true})
+		if err != nil {
+			return false, err
+		}
+		if locked > 0 {
+			return false, errTwoFactorLocked
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if tf.FailedAttempts > twoFactorMaxFailures {
+		_, err := collection.UpdateOne(ctx, bson.M{"user_id": userID},
+			bson.M{"$set": bson.M{"locked_until": now.Add(twoFactorLockout), "failed_attempts": 0}})
+		if err != nil {
+			return false, err
+		}
+		return false, errTwoFactorLocked
+	}
+
+	// An accepted code clears the failures counted so far
+	if step, ok := auth.ValidateTOTP(tf.Secret, code, now); ok {
+		result, err := collection.UpdateOne(ctx,
+			bson.M{"user_id": userID, "last_used_step": bson.M{"$lt": step}},
+			bson.M{"$set": bson.M{"last_used_step": step, "failed_attempts": 0}})
+		if err != nil {
+			return false, err
+		}
+		return result.ModifiedCount == 1, nil
+	}
+
+	hash := auth.HashToken(auth.NormalizeRecoveryCode(code))
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"user_id": userID, "enabled": true, "recovery_codes": hash},
+		bson.M{"$pull": bson.M{"recovery_codes": hash}, "$set": bson.M{"failed_attempts": 0}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// hashRecoveryCodes returns the stored form of freshly generated recovery codes
+func hashRecoveryCodes(codes []string) []string {
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashToken(code)
+	}
+	return hashes
+}
+
+// VerifyTwoFactorLogin completes a login with the challenge token from Login and a
+// code from the authenticator app, or one of the recovery codes
+func VerifyTwoFactorLogin(c *gin.Context) {
+	var input struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Count the attempt before checking the code, so guesses are capped per challenge
+	challenges := db.Client.Database("fintrack").Collection("login_challenges")
+	var challenge models.LoginChallenge
+	err := challenges.FindOneAndUpdate(ctx,
+		bson.M{
+			"token_hash": auth.HashToken(input.ChallengeToken),
+			"expires_at": bson.M{"$gt": time.Now()},
+			"attempts":   bson.M{"$lt": loginChallengeAttempts},
+		},
+		bson.M{"$inc": bson.M{"attempts": 1}}).Decode(&challenge)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired, please log in again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+
+	ok, err := verifySecondFactor(ctx, challenge.UserID, input.Code)
+	if err == errTwoFactorLocked {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many invalid codes, please try again later"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	// The challenge must not be usable for a second session
+	result, err := challenges.DeleteOne(ctx, bson.M{"_id": challenge.ID})
+	if err != nil || result.DeletedCount == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired, please log in again"})
+		return
+	}
+
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": challenge.UserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired, please log in again"})
+		return
+	}
+
+	tokens, err := startSession(ctx, c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse("Login successful", user, tokens))
+}
+
+// GetTwoFactorStatus reports whether 2FA is enabled and how many recovery codes are left
+func GetTwoFactorStatus(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	var tf models.TwoFactor
+	err := db.Client.Database("fintrack").Collection("two_factor").FindOne(ctx,
+		bson.M{"user_id": userObjectID, "enabled": true}).Decode(&tf)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch two-factor status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":                  true,
+		"enabled_at":               tf.EnabledAt,
+		"recovery_codes_remaining": len(tf.RecoveryCodes),
+	})
+}
+
+// SetupTwoFactor starts enrollment: it generates a secret and returns it with an
+// otpauth:// URI for the QR code. 2FA stays off until EnableTwoFactor confirms a code.
+func SetupTwoFactor(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": userObjectID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	collection := db.Client.Database("fintrack").Collection("two_factor")
+	enabled, err := collection.CountDocuments(ctx, bson.M{"user_id": userObjectID, "enabled": true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor setup"})
+		return
+	}
+	if enabled > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+
+	// An unfinished setup is replaced; the unique index on user_id stops a race with
+	// a concurrent enable
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"user_id": userObjectID, "enabled": false},
+		bson.M{"$set": bson.M{
+			"secret":         secret,
+			"recovery_codes": []string{},
+			"last_used_step": 0,
+			"created_at":     time.Now(),
+		}},
+		options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor setup"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": auth.TOTPURI(secret, user.Email, totpIssuer),
+	})
+}
+
+// EnableTwoFactor confirms the secret from SetupTwoFactor with a code from the
+// authenticator app, turns 2FA on and returns the recovery codes. This is the only
+// time the codes are shown.
+func EnableTwoFactor(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	collection := db.Client.Database("fintrack").Collection("two_factor")
+	var tf models.TwoFactor
+	err := collection.FindOne(ctx, bson.M{"user_id": userObjectID, "enabled": false}).Decode(&tf)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start two-factor setup first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(tf.Secret, input.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	codes, err := auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	now := time.Now()
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"user_id": userObjectID, "enabled": false, "secret": tf.Secret},
+		bson.M{"$set": bson.M{
+			"enabled":        true,
+			"enabled_at":     now,
+			"recovery_codes": hashRecoveryCodes(codes),
+			"last_used_step": step,
+		}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	if result.ModifiedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor setup changed, please start again"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor turns 2FA off after checking a current code or a recovery code
+func DisableTwoFactor(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ok, err := verifySecondFactor(ctx, userObjectID, input.Code)
+	if err == errTwoFactorLocked {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many invalid codes, please try again later"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	if _, err := db.Client.Database("fintrack").Collection("two_factor").DeleteOne(ctx, bson.M{"user_id": userObjectID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a current code
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ok, err := verifySecondFactor(ctx, userObjectID, input.Code)
+	if err == errTwoFactorLocked {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many invalid codes, please try again later"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	codes, err := auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	_, err = db.Client.Database("fintrack").Collection("two_factor").UpdateOne(ctx,
+		bson.M{"user_id": userObjectID, "enabled": true},
+		bson.M{"$set": bson.M{"recovery_codes": hashRecoveryCodes(codes)}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
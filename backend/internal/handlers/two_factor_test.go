@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"fintrack-backend/internal/auth"
+)
+
+func TestVerifyTwoFactorLoginRequiresTokenAndCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, body := range []string{
+		`{}`,
+		`{"challenge_token":"abc"}`,
+		`{"code":"123456"}`,
+		`not json`,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/2fa/verify", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		VerifyTwoFactorLogin(c)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: status = %d, want 400", body, w.Code)
+		}
+	}
+}
+
+func TestHashRecoveryCodesMatchesTypedCodes(t *testing.T) {
+	codes, err := auth.NewRecoveryCodes(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := hashRecoveryCodes(codes)
+	for i, code := range codes {
+		// verifySecondFactor looks codes up by the hash of their normalized form
+		typed := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+		if got := auth.HashToken(auth.NormalizeRecoveryCode(typed)); got != hashes[i] {
+			t.Errorf("typed %q hashes to %s, stored %s", typed, got, hashes[i])
+		}
+		if hashes[i] == code {
+			t.Errorf("code %q stored in plain text", code)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"fintrack-backend/internal/auth"
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/email"
+	"fintrack-backend/internal/models"
+)
+
+const (
+	// How long an emailed verification link can be used
+	emailVerificationTTL = 24 * time.Hour
+	// Minimum wait between verification emails, and the daily cap
+	verificationResendInterval = time.Minute
+	verificationsPerDay        = 5
+)
+
+// sendVerificationEmail emails user a link confirming their current address
+func sendVerificationEmail(ctx context.Context, user models.User) error {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = db.Client.Database("fintrack").Collection("email_verifications").InsertOne(ctx, models.EmailVerification{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: hash,
+		ExpiresAt: now.Add(emailVerificationTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{
+		"Email":     user.Email,
+		"URL":       email.AppURL() + "/verify-email?token=" + url.QueryEscape(token),
+		"ExpiresIn": "24 hours",
+	}
+	return email.Send(ctx, user, "", "verify_email", data)
+}
+
+// sendVerificationEmailAsync sends the verification email in the background, e.g. right after registration
+func sendVerificationEmailAsync(user models.User) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := sendVerificationEmail(ctx, user); err != nil {
+			log.Printf("Verification email for user %s could not be sent: %v", user.ID.Hex(), err)
+		}
+	}()
+}
+
+// VerifyEmail marks the user's address as verified using an emailed token
+func VerifyEmail(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var verification models.EmailVerification
+	err := db.Client.Database("fintrack").Collection("email_verifications").FindOneAndUpdate(ctx,
+		claimTokenFilter(input.Token, now), bson.M{"$set": bson.M{"used_at": now}}).Decode(&verification)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	// Only the address the link was sent to can be verified by it
+	result, err := db.GetCollection("users").UpdateOne(ctx,
+		bson.M{"_id": verification.UserID, "email": verification.Email},
+		bson.M{"$set": bson.M{"email_verified": true, "updated_at": now}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// verificationResendLimited reports whether another verification email would exceed
+// the limits, given how many were sent in the last minute and the last day
+func verificationResendLimited(lastMinute, lastDay int64) bool {
+	return lastMinute > 0 || lastDay >= verificationsPerDay
+}
+
+// ResendVerificationEmail sends a new verification link to the current user,
+// at most once a minute and a few times a day
+func ResendVerificationEmail(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": userObjectID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.EmailVerified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	verifications := db.Client.Database("fintrack").Collection("email_verifications")
+	now := time.Now()
+	recent, err := verifications.CountDocuments(ctx, bson.M{"user_id": userObjectID, "created_at": bson.M{"$gt": now.Add(-verificationResendInterval)}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	today, err := verifications.CountDocuments(ctx, bson.M{"user_id": userObjectID, "created_at": bson.M{"$gt": now.Add(-24 * time.Hour)}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	if verificationResendLimited(recent, today) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many verification emails, please try again later"})
+		return
+	}
+
+	if err := sendVerificationEmail(ctx, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
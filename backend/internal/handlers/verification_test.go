@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVerificationResendLimited(t *testing.T) {
+	tests := []struct {
+		name              string
+		lastMinute, today int64
+		limited           bool
+	}{
+		{"first email", 0, 0, false},
+		{"a few today", 0, verificationsPerDay - 1, false},
+		{"within a minute", 1, 1, true},
+		{"daily cap reached", 0, verificationsPerDay, true},
+		{"over the cap", 0, verificationsPerDay + 2, true},
+	}
+	for _, tt := range tests {
+		if got := verificationResendLimited(tt.lastMinute, tt.today); got != tt.limited {
+			t.Errorf("%s: limited = %v, want %v", tt.name, got, tt.limited)
+		}
+	}
+}
+
+func TestVerifyEmailRejectsInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, body := range []string{`{}`, `{"token": ""}`, `not json`} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/verify-email", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		VerifyEmail(c)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, w.Code)
+		}
+	}
+}
+
+func TestResendVerificationEmailRequiresUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/resend-verification", nil)
+	ResendVerificationEmail(c)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Job is a background task run periodically by the scheduler
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Next, when set, returns the next run time after now instead of ticking every Interval
+	Next func(now time.Time) time.Time
+	Run  func(ctx context.Context) error
+}
+
+// Maximum run time of a single job execution. Jobs that work through every user
+// should give each user a deadline of their own rather than share this one.
+const jobTimeout = 5 * time.Minute
+
+var registered []Job
+
+// Every registers a job that runs once per interval after Start
+func Every(name string, interval time.Duration, run func(ctx context.Context) error) {
+	registered = append(registered, Job{Name: name, Interval: interval, Run: run})
+}
+
+// Daily registers a job that runs once a day at the given UTC time
+func Daily(name string, hour, minute int, run func(ctx context.Context) error) {
+	next := func(now time.Time) time.Time {
+		now = now.UTC()
+		t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, time.UTC)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t
+	}
+	registered = append(registered, Job{Name: name, Interval: 24 * time.Hour, Next: next, Run: run})
+}
+
+// Weekly registers a job that runs once a week on the given weekday at the given UTC time
+func Weekly(name string, weekday time.Weekday, hour, minute int, run func(ctx context.Context) error) {
+	next := func(now time.Time) time.Time {
+		now = now.UTC()
+		days := (int(weekday) - int(now.Weekday()) + 7) % 7
+		t := time.Date(now.Year(), now.Month(), now.Day()+days, hour, minute, 0, 0, time.UTC)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 7)
+		}
+		return t
+	}
+	registered = append(registered, Job{Name: name, Interval: 7 * 24 * time.Hour, Next: next, Run: run})
+}
+
+// Monthly registers a job that runs on the given day (1-28) of every month at the given UTC time
+func Monthly(name string, day, hour, minute int, run func(ctx context.Context) error) {
+	next := func(now time.Time) time.Time {
+		now = now.UTC()
+		t := time.Date(now.Year(), now.Month(), day, hour, minute, 0, 0, time.UTC)
+		if !t.After(now) {
+			t = time.Date(now.Year(), now.Month()+1, day, hour, minute, 0, 0, time.UTC)
+		}
+		return t
+	}
+	registered = append(registered, Job{Name: name, Next: next, Run: run})
+}
+
+// Start launches all registered jobs in the background
+func Start() {
+	for _, job := range registered {
+		go loop(job)
+	}
+}
+
+func loop(job Job) {
+	if job.Next != nil {
+		for {
+			time.Sleep(time.Until(job.Next(time.Now())))
+			runOnce(job)
+		}
+	}
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		runOnce(job)
+	}
+}
+
+func runOnce(job Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[jobs] %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	if err := job.Run(ctx); err != nil {
+		log.Printf("[jobs] %s failed: %v", job.Name, err)
+	}
+}
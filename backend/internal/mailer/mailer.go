@@ -0,0 +1,141 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Mailer sends email messages to a single recipient
+type Mailer interface {
+	Send(to, subject, body string) error
+	// SendMessage sends a message with optional HTML alternative, headers and attachments
+	SendMessage(msg Message) error
+}
+
+// SMTPMailer delivers mail through an SMTP relay configured via environment variables
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	return m.SendMessage(Message{To: to, Subject: subject, Text: body})
+}
+
+func (m *SMTPMailer) SendMessage(msg Message) error {
+	data, err := msg.Bytes(m.From)
+	if err != nil {
+		return err
+	}
+
+	// Local sinks (MailHog, Mailpit, ...) accept unauthenticated mail, so auth is only
+	// used when a username is configured
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(m.Host+":"+m.Port, auth, envelopeAddress(m.From), []string{msg.To}, data)
+}
+
+// envelopeAddress extracts the bare address from a "Name <address>" header value
+func envelopeAddress(from string) string {
+	if start := strings.LastIndex(from, "<"); start >= 0 {
+		if end := strings.LastIndex(from, ">"); end > start {
+			return from[start+1 : end]
+		}
+	}
+	return from
+}
+
+// LogMailer prints messages to the server log, used when SMTP is not configured.
+// Bodies carry live single-use links (password reset, email verification), so they
+// are only printed with ShowBody, which Default sets from MAILER_LOG_BODY=true for
+// local development.
+type LogMailer struct {
+	ShowBody bool
+}
+
+func (l LogMailer) Send(to, subject, body string) error {
+	if !l.ShowBody {
+		log.Printf("[mailer] to=%s subject=%q (%d byte body not logged)", to, subject, len(body))
+		return nil
+	}
+	log.Printf("[mailer] to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
+
+func (l LogMailer) SendMessage(msg Message) error {
+	names := make([]string, len(msg.Attachments))
+	for i, a := range msg.Attachments {
+		names[i] = a.Filename
+	}
+	if len(names) > 0 {
+		log.Printf("[mailer] attachments: %s", strings.Join(names, ", "))
+	}
+	return l.Send(msg.To, msg.Subject, msg.Text)
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultMailer Mailer
+	defaultOnce   sync.Once
+)
+
+// Default returns the SMTP mailer when SMTP_HOST is set, otherwise a LogMailer.
+// For local development point SMTP_HOST/SMTP_PORT at a sink such as MailHog
+// (localhost:1025) and leave SMTP_USERNAME empty, or set MAILER_LOG_BODY=true to
+// print whole messages to the log.
+// The environment is read once; Default is safe to call from any goroutine.
+func Default() Mailer {
+	defaultOnce.Do(func() {
+		m := fromEnv()
+		defaultMu.Lock()
+		if defaultMailer == nil { // SetDefault was called first
+			defaultMailer = m
+		}
+		defaultMu.Unlock()
+	})
+
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultMailer
+}
+
+func fromEnv() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return LogMailer{ShowBody: os.Getenv("MAILER_LOG_BODY") == "true"}
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = fmt.Sprintf("FinTrack <no-reply@%s>", host)
+	}
+
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}
+}
+
+// SetDefault overrides the mailer returned by Default (e.g. for a local sink)
+func SetDefault(m Mailer) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultMailer = m
+}
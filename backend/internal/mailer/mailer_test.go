@@ -0,0 +1,166 @@
+package mailer
+
+import (
+	"bufio"
+	"log"
+	"net"
+	"net/mail"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// smtpSink is a minimal local SMTP server that records the last message it received
+type smtpSink struct {
+	listener net.Listener
+	from     string
+	to       []string
+	data     chan string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpSink{listener: l, data: make(chan string, 1)}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *smtpSink) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.from = strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.to = append(s.to, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.data <- data.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailerDeliversToSink(t *testing.T) {
+	sink := newSMTPSink(t)
+	host, port, _ := net.SplitHostPort(sink.listener.Addr().String())
+
+	m := &SMTPMailer{Host: host, Port: port, From: "FinTrack <no-reply@fintrack.test>"}
+	err := m.SendMessage(Message{
+		To:      "jane@example.com",
+		Subject: "Weekly digest",
+		Text:    "Your week",
+		HTML:    "<p>Your week</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/u>"},
+	})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	data := <-sink.data
+	if sink.from != "no-reply@fintrack.test" {
+		t.Errorf("envelope from = %q", sink.from)
+	}
+	if len(sink.to) != 1 || sink.to[0] != "jane@example.com" {
+		t.Errorf("envelope to = %v", sink.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if got := msg.Header.Get("Subject"); got != "Weekly digest" {
+		t.Errorf("Subject = %q", got)
+	}
+	if got := msg.Header.Get("List-Unsubscribe"); got != "<https://example.com/u>" {
+		t.Errorf("List-Unsubscribe = %q", got)
+	}
+	if ct := msg.Header.Get("Content-Type"); !strings.HasPrefix(ct, "multipart/alternative") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestLogMailerRedactsBody(t *testing.T) {
+	var buf strings.Builder
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	body := "Reset your password: https://app.example.com/reset-password?token=secret-token"
+	LogMailer{}.Send("jane@example.com", "Reset your password", body)
+	if strings.Contains(buf.String(), "secret-token") {
+		t.Errorf("log contains the body: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "jane@example.com") {
+		t.Errorf("log is missing the recipient: %s", buf.String())
+	}
+
+	buf.Reset()
+	LogMailer{ShowBody: true}.Send("jane@example.com", "Reset your password", body)
+	if !strings.Contains(buf.String(), "secret-token") {
+		t.Errorf("ShowBody log is missing the body: %s", buf.String())
+	}
+}
+
+func TestDefaultConcurrentWithSetDefault(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if Default() == nil {
+				t.Error("Default returned nil")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			SetDefault(LogMailer{})
+		}()
+	}
+	wg.Wait()
+
+	sink := LogMailer{ShowBody: true}
+	SetDefault(sink)
+	if Default() != sink {
+		t.Error("Default ignores SetDefault")
+	}
+}
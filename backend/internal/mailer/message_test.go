@@ -0,0 +1,142 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func parseMessage(t *testing.T, m Message) *mail.Message {
+	t.Helper()
+	data, err := m.Bytes("FinTrack <no-reply@fintrack.test>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v\n%s", err, data)
+	}
+	return msg
+}
+
+func TestMessageHeadersStayOnOneLine(t *testing.T) {
+	msg := parseMessage(t, Message{
+		To:      "jane@example.com\r\nBcc: victim@example.com",
+		Subject: "Hello\r\nBcc: victim@example.com",
+		Text:    "Body",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/u>\r\nX-Injected: 1"},
+	})
+
+	if bcc := msg.Header.Get("Bcc"); bcc != "" {
+		t.Errorf("Bcc header injected: %q", bcc)
+	}
+	if injected := msg.Header.Get("X-Injected"); injected != "" {
+		t.Errorf("X-Injected header injected: %q", injected)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Hello\r\nBcc: victim@example.com" {
+		t.Errorf("Subject = %q", subject)
+	}
+}
+
+func TestMessagePlainText(t *testing.T) {
+	msg := parseMessage(t, Message{To: "jane@example.com", Subject: "Hi", Text: "Grüße"})
+
+	if ct := msg.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if string(body) != "Grüße" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestMessageMultipartStructure(t *testing.T) {
+	pdf := bytes.Repeat([]byte("%PDF-1.4 statement "), 20)
+	msg := parseMessage(t, Message{
+		To:          "jane@example.com",
+		Subject:     "Your statement",
+		Text:        "See attached",
+		HTML:        "<p>See attached</p>",
+		Attachments: []Attachment{{Filename: "statement-2026-03.pdf", ContentType: "application/pdf", Data: pdf}},
+	})
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, %v", mediaType, err)
+	}
+	mixed := multipart.NewReader(msg.Body, params["boundary"])
+
+	// First part: the text and HTML alternatives
+	part, err := mixed.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err = mime.ParseMediaType(part.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("first part Content-Type = %q, %v", mediaType, err)
+	}
+	alt := multipart.NewReader(part, params["boundary"])
+	for _, want := range []struct{ contentType, body string }{
+		{"text/plain", "See attached"},
+		{"text/html", "<p>See attached</p>"},
+	} {
+		p, err := alt.NextRawPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := p.Header.Get("Content-Type"); !strings.HasPrefix(ct, want.contentType) {
+			t.Errorf("alternative Content-Type = %q, want %s", ct, want.contentType)
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(p))
+		if string(body) != want.body {
+			t.Errorf("%s body = %q, want %q", want.contentType, body, want.body)
+		}
+	}
+	if _, err := alt.NextPart(); err != io.EOF {
+		t.Errorf("extra alternative part: %v", err)
+	}
+
+	// Second part: the attachment
+	part, err = mixed.NextRawPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := part.Header.Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("attachment Content-Type = %q", ct)
+	}
+	_, dispParams, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+	if err != nil || dispParams["filename"] != "statement-2026-03.pdf" {
+		t.Errorf("attachment disposition = %q, %v", part.Header.Get("Content-Disposition"), err)
+	}
+	data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+	if err != nil || !bytes.Equal(data, pdf) {
+		t.Errorf("attachment data mismatch (%v)", err)
+	}
+
+	if _, err := mixed.NextPart(); err != io.EOF {
+		t.Errorf("extra part: %v", err)
+	}
+}
+
+func TestEnvelopeAddress(t *testing.T) {
+	tests := map[string]string{
+		"FinTrack <no-reply@fintrack.test>": "no-reply@fintrack.test",
+		"no-reply@fintrack.test":            "no-reply@fintrack.test",
+	}
+	for in, want := range tests {
+		if got := envelopeAddress(in); got != want {
+			t.Errorf("envelopeAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
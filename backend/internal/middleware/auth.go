@@ -1,13 +1,24 @@
 package middleware
 
 import (
+	"context"
 	"fintrack-backend/internal/auth"
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// How often a session's last-used time is written back while it is in use
+const sessionTouchInterval = 5 * time.Minute
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -32,8 +43,42 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens must belong to a session that has not been revoked
+		userID, _ := primitive.ObjectIDFromHex(claims.UserID)
+		sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired, please log in again"})
+			c.Abort()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		sessions := db.Client.Database("fintrack").Collection("sessions")
+		var session models.Session
+		err = sessions.FindOne(ctx,
+			bson.M{"_id": sessionID, "user_id": userID, "revoked_at": nil},
+			options.FindOne().SetProjection(bson.M{"last_used_at": 1})).Decode(&session)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
+			c.Abort()
+			return
+		}
+
+		if time.Since(session.LastUsedAt) > sessionTouchInterval {
+			sessions.UpdateOne(ctx, bson.M{"_id": sessionID},
+				bson.M{"$set": bson.M{"last_used_at": time.Now(), "ip": c.ClientIP()}})
+		}
+
 		// Set userID in context for handlers to use
 		c.Set("userID", claims.UserID)
+		c.Set("sessionID", claims.SessionID)
 		c.Next()
 	}
 }
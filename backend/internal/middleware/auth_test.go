@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"fintrack-backend/internal/auth"
+)
+
+// These requests are all rejected before the session lookup, so they need no database
+func TestAuthMiddlewareRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	withSession, _ := auth.GenerateToken("64b000000000000000000001", "64b000000000000000000002")
+	withoutSession, _ := auth.GenerateToken("64b000000000000000000001", "")
+
+	tests := []struct {
+		name   string
+		header string
+		error  string
+	}{
+		{"missing header", "", "Authorization header required"},
+		{"basic auth", "Basic dXNlcjpwYXNz", "Invalid authorization header format"},
+		{"lowercase scheme", "bearer " + withSession, "Invalid authorization header format"},
+		{"extra field", "Bearer " + withSession + " x", "Invalid authorization header format"},
+		{"invalid token", "Bearer not-a-token", "Invalid or expired token"},
+		{"token without session", "Bearer " + withoutSession, "Session expired, please log in again"},
+	}
+	for _, tt := range tests {
+		r := gin.New()
+		r.GET("/", AuthMiddleware(), func(c *gin.Context) {
+			t.Errorf("%s: handler reached", tt.name)
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		r.ServeHTTP(w, req)
+
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != http.StatusUnauthorized || body.Error != tt.error {
+			t.Errorf("%s: got %d %q, want 401 %q", tt.name, w.Code, body.Error, tt.error)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"fintrack-backend/internal/db"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RequireVerifiedEmail rejects users who have not verified their email address yet.
+// It guards features such as exports and is only enforced when
+// REQUIRE_VERIFIED_EMAIL=true, so existing deployments can opt in. It must run
+// after AuthMiddleware.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if os.Getenv("REQUIRE_VERIFIED_EMAIL") != "true" {
+			c.Next()
+			return
+		}
+
+		userID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var user struct {
+			EmailVerified bool `bson:"email_verified"`
+		}
+		err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": userID},
+			options.FindOne().SetProjection(bson.M{"email_verified": 1})).Decode(&user)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		if !user.EmailVerified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address to use this feature", "code": "email_not_verified"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Account is a real-world money container (bank account, cash, card) whose balance is
+// its opening balance plus all transactions booked against it
+type Account struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name           string             `bson:"name" json:"name"`
+	Type           string             `bson:"type" json:"type"` // "checking", "savings", "cash", "credit", "investment" or "loan"
+	OpeningBalance float64            `bson:"opening_balance" json:"opening_balance"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// AccountSummary is an account with its computed balance and goal allocations
+type AccountSummary struct {
+	Account     `bson:",inline"`
+	Balance     float64 `json:"balance"`
+	Allocated   float64 `json:"allocated"`   // Part of the balance backing savings goals
+	Unallocated float64 `json:"unallocated"` // Balance - Allocated
+}
+
+// GoalAccountLink backs a goal with an account: either a percentage of its balance
+// or a fixed amount (capped at the balance)
+type GoalAccountLink struct {
+	AccountID  primitive.ObjectID `bson:"account_id" json:"account_id"`
+	Percentage float64            `bson:"percentage,omitempty" json:"percentage,omitempty"` // 0-100
+	Amount     float64            `bson:"amount,omitempty" json:"amount,omitempty"`
+}
+
+// Value returns the part of balance this link contributes to the goal
+func (l GoalAccountLink) Value(balance float64) float64 {
+	if balance <= 0 {
+		return 0
+	}
+	if l.Percentage > 0 {
+		return balance * l.Percentage / 100
+	}
+	if l.Amount > balance {
+		return balance
+	}
+	return l.Amount
+}
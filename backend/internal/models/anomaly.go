@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Anomaly kinds
+const (
+	AnomalyCategorySpike    = "category_spike"    // Category spend far above its rolling average
+	AnomalyLargeTransaction = "large_transaction" // Unusually large charge for a known payee
+	AnomalyNewMerchant      = "new_merchant"      // Large charge at a first-time payee
+)
+
+// Anomaly review states
+const (
+	AnomalyOpen      = "open"
+	AnomalyReviewed  = "reviewed"
+	AnomalyDismissed = "dismissed"
+)
+
+// Anomaly is an unusual spending pattern found by the anomaly detector
+type Anomaly struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Kind          string              `bson:"kind" json:"kind"`
+	Key           string              `bson:"key" json:"-"` // Deduplicates findings: transaction ID or category+period
+	TransactionID *primitive.ObjectID `bson:"transaction_id,omitempty" json:"transaction_id,omitempty"`
+	Category      string              `bson:"category,omitempty" json:"category,omitempty"`
+	Merchant      string              `bson:"merchant,omitempty" json:"merchant,omitempty"`
+	Period        string              `bson:"period,omitempty" json:"period,omitempty"` // "2006-01" for category spikes
+	Amount        float64             `bson:"amount" json:"amount"`
+	Expected      float64             `bson:"expected" json:"expected"` // Baseline the amount was compared against
+	Score         float64             `bson:"score" json:"score"`       // Standard deviations above the baseline, 0 if not applicable
+	Message       string              `bson:"message" json:"message"`
+	Status        string              `bson:"status" json:"status"` // "open", "reviewed" or "dismissed"
+	DetectedAt    time.Time           `bson:"detected_at" json:"detected_at"`
+	ReviewedAt    *time.Time          `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+}
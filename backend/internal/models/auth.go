@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken is a stored refresh token. Only its hash is kept. Each refresh
+// marks the token rotated and issues a successor in the same session, so a rotated
+// token coming back means it was stolen and the whole session is revoked.
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	SessionID primitive.ObjectID `bson:"session_id" json:"session_id"` // Shared by all tokens rotated from one login
+	TokenHash string             `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	RotatedAt *time.Time         `bson:"rotated_at,omitempty" json:"rotated_at,omitempty"`
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// Session is one login on one device. Access tokens carry its ID and are rejected
+// once it is revoked; its refresh tokens share the ID.
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	UserAgent  string             `bson:"user_agent" json:"user_agent"`
+	IP         string             `bson:"ip" json:"ip"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt time.Time          `bson:"last_used_at" json:"last_used_at"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expires_at"` // Expiry of its latest refresh token
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	Current    bool               `bson:"-" json:"current"` // Set when listing: the session making the request
+}
+
+// TokenPair is returned on login and refresh
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Access token lifetime in seconds
+}
+
+// PasswordReset is an emailed, single-use password reset token; only its hash is stored
+type PasswordReset struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
+
+// EmailVerification is an emailed token confirming that the user owns Email; only
+// its hash is stored. Changing the address leaves older tokens unusable.
+type EmailVerification struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Email     string             `bson:"email" json:"email"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
+
+// TwoFactor holds a user's TOTP enrollment. The secret is saved at setup and only
+// takes effect once a code from the authenticator app has confirmed it.
+type TwoFactor struct {
+	UserID        primitive.ObjectID `bson:"user_id" json:"-"`
+	Secret        string             `bson:"secret" json:"-"`
+	Enabled       bool               `bson:"enabled" json:"enabled"`
+	RecoveryCodes []string           `bson:"recovery_codes" json:"-"` // Hashes of the unused recovery codes
+	LastUsedStep  int64              `bson:"last_used_step" json:"-"` // Time step of the last accepted code, against replays
+	// Codes tried since the last accepted one; reaching the limit locks 2FA checks until LockedUntil
+	FailedAttempts int        `bson:"failed_attempts" json:"-"`
+	LockedUntil    *time.Time `bson:"locked_until,omitempty" json:"-"`
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+	EnabledAt      *time.Time `bson:"enabled_at,omitempty" json:"enabled_at,omitempty"`
+}
+
+// LoginChallenge is the pending second step of a login for a user with 2FA
+type LoginChallenge struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	Attempts  int                `bson:"attempts" json:"attempts"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// OAuthCode is a one-time code handed to the web app after a provider login; the
+// app exchanges it for a session so that no tokens appear in redirect URLs
+type OAuthCode struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CodeHash  string             `bson:"code_hash" json:"-"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
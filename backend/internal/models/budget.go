@@ -7,23 +7,137 @@ import (
 )
 
 type BudgetCategory struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Name      string             `bson:"name" json:"name"`             // Matches Transaction.Category
-	Limit     float64            `bson:"limit" json:"limit"`           // Budget limit amount
-	Icon      string             `bson:"icon" json:"icon,omitempty"`   // Icon name for frontend mapping
-	Color     string             `bson:"color" json:"color,omitempty"` // Color code/name
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name       string             `bson:"name" json:"name"`                                 // Matches Transaction.Category
+	Kind       string             `bson:"kind,omitempty" json:"kind,omitempty"`             // "expense" (default) or "income"
+	Limit      float64            `bson:"limit" json:"limit"`                               // Budget limit, or expected amount for income
+	Icon       string             `bson:"icon" json:"icon,omitempty"`                       // Icon name for frontend mapping
+	Color      string             `bson:"color" json:"color,omitempty"`                     // Color code/name
+	Thresholds []float64          `bson:"thresholds,omitempty" json:"thresholds,omitempty"` // Alert levels in % of Limit
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
-// Response struct for the budget overview API
+const (
+	BudgetKindExpense = "expense"
+	BudgetKindIncome  = "income"
+)
+
+// IsIncome reports whether the category tracks expected income rather than a spending limit
+func (b BudgetCategory) IsIncome() bool {
+	return b.Kind == BudgetKindIncome
+}
+
+// ValidKind reports whether Kind is empty (an expense budget) or a known kind
+func (b BudgetCategory) ValidKind() bool {
+	return b.Kind == "" || b.Kind == BudgetKindExpense || b.Kind == BudgetKindIncome
+}
+
+// DefaultBudgetThresholds mirror the WARNING and CRITICAL levels of the overview
+var DefaultBudgetThresholds = []float64{80, 100}
+
+// AlertThresholds returns the configured thresholds or the defaults
+func (b BudgetCategory) AlertThresholds() []float64 {
+	if len(b.Thresholds) == 0 {
+		return DefaultBudgetThresholds
+	}
+	return b.Thresholds
+}
+
+// MaxBudgetThreshold is the highest alert level accepted, in % of Limit
+const MaxBudgetThreshold = 1000
+
+// ValidThresholds reports whether every configured alert level is within 0-MaxBudgetThreshold
+func (b BudgetCategory) ValidThresholds() bool {
+	for _, t := range b.Thresholds {
+		if t < 0 || t > MaxBudgetThreshold {
+			return false
+		}
+	}
+	return true
+}
+
+// Semantic category status values of the presentation-neutral overview
+const (
+	BudgetStatusOnTrack  = "on_track"
+	BudgetStatusWarning  = "warning"
+	BudgetStatusCritical = "critical"
+)
+
+// BudgetStatusThresholds are the percentages of the limit at which a category changes status
+type BudgetStatusThresholds struct {
+	Warning  float64 `json:"warning"`
+	Critical float64 `json:"critical"`
+}
+
+var DefaultStatusThresholds = BudgetStatusThresholds{Warning: 80, Critical: 100}
+
+// BudgetOverview is the presentation-neutral budget summary served by /api/v2/budget.
+// It carries semantic statuses and raw color tokens; rendering is left to the client.
+type BudgetOverview struct {
+	Version        int                      `json:"version"`
+	PeriodStart    time.Time                `json:"periodStart"`
+	PeriodEnd      time.Time                `json:"periodEnd"`
+	DaysElapsed    int                      `json:"daysElapsed"`
+	DaysInPeriod   int                      `json:"daysInPeriod"`
+	Thresholds     BudgetStatusThresholds   `json:"thresholds"`
+	TotalBudget    float64                  `json:"totalBudget"`
+	Spent          float64                  `json:"spent"`
+	Remaining      float64                  `json:"remaining"`
+	PercentageUsed float64                  `json:"percentageUsed"`
+	Status         string                   `json:"status"`
+	ProjectedSpend float64                  `json:"projectedSpend"`
+	AtRiskCount    int                      `json:"atRiskCount"`
+	Categories     []BudgetCategoryOverview `json:"categories"`
+
+	ExpectedIncome     float64        `json:"expectedIncome"`
+	ReceivedIncome     float64        `json:"receivedIncome"`
+	PlannedSavingsRate float64        `json:"plannedSavingsRate"`
+	ActualSavingsRate  float64        `json:"actualSavingsRate"`
+	IncomeCategories   []IncomeStatus `json:"incomeCategories"`
+}
+
+type BudgetCategoryOverview struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Limit           float64          `json:"limit"`
+	Spent           float64          `json:"spent"`
+	Percentage      float64          `json:"percentage"`
+	Status          string           `json:"status"` // BudgetStatus* value
+	Icon            string           `json:"icon"`
+	Color           string           `json:"color"` // Color token as saved on the category, e.g. "orange"
+	AlertThresholds []float64        `json:"alertThresholds"`
+	Forecast        CategoryForecast `json:"forecast"`
+}
+
+// BudgetOverviewResponse is the v1 budget overview consumed by the web client
 type BudgetOverviewResponse struct {
 	TotalBudget    float64          `json:"totalBudget"`
 	SpentSoFar     float64          `json:"spentSoFar"`
 	Remaining      float64          `json:"remaining"`
 	PercentageUsed float64          `json:"percentageUsed"`
 	Categories     []CategoryStatus `json:"categories"`
+	ProjectedSpend float64          `json:"projectedSpend"` // Sum of category forecasts
+	AtRiskCount    int              `json:"atRiskCount"`    // Categories projected to exceed their limit
+	DaysElapsed    int              `json:"daysElapsed"`    // Days into the current period
+	DaysInPeriod   int              `json:"daysInPeriod"`
+
+	ExpectedIncome     float64        `json:"expectedIncome"`     // Sum of income category expectations
+	ReceivedIncome     float64        `json:"receivedIncome"`     // All income received this period
+	PlannedSavingsRate float64        `json:"plannedSavingsRate"` // % of expected income not allocated to expense budgets
+	ActualSavingsRate  float64        `json:"actualSavingsRate"`  // % of received income not spent so far
+	IncomeCategories   []IncomeStatus `json:"incomeCategories"`
+}
+
+// IncomeStatus compares expected and received income for an income category
+type IncomeStatus struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Expected   float64 `json:"expected"`
+	Received   float64 `json:"received"`
+	Percentage float64 `json:"percentage"`
+	Icon       string  `json:"icon"`
 }
 
 type CategoryStatus struct {
@@ -38,4 +152,16 @@ type CategoryStatus struct {
 	IconColor     string  `json:"iconColor"`
 	IconBg        string  `json:"iconBg"`
 	ProgressColor string  `json:"progressColor"`
+
+	Forecast CategoryForecast `json:"forecast"`
+}
+
+// CategoryForecast projects end-of-period spending for a budget category
+type CategoryForecast struct {
+	ProjectedSpend      float64 `json:"projectedSpend"`
+	ProjectedPercentage float64 `json:"projectedPercentage"`
+	ProjectedOverLimit  bool    `json:"projectedOverLimit"`
+	PaceProjection      float64 `json:"paceProjection"`    // Non-recurring spend so far extrapolated linearly, plus the period's recurring charges
+	HistoricalShare     float64 `json:"historicalShare"`   // Avg share of monthly spend reached by this point in prior months (0 if unknown)
+	UpcomingRecurring   float64 `json:"upcomingRecurring"` // Scheduled recurring expenses still due this period
 }
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BudgetTemplate is a reusable set of budget categories expressed as shares of income
+type BudgetTemplate struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID   `bson:"user_id" json:"user_id"`
+	Key         string               `bson:"-" json:"key,omitempty"` // Identifier of a built-in template
+	Name        string               `bson:"name" json:"name"`
+	Description string               `bson:"description" json:"description,omitempty"`
+	Builtin     bool                 `bson:"-" json:"builtin"`
+	Items       []BudgetTemplateItem `bson:"items" json:"items"`
+	CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+}
+
+type BudgetTemplateItem struct {
+	Name  string  `bson:"name" json:"name"`   // Budget category name
+	Share float64 `bson:"share" json:"share"` // Fraction of monthly income, e.g. 0.25
+	Icon  string  `bson:"icon" json:"icon,omitempty"`
+	Color string  `bson:"color" json:"color,omitempty"`
+}
+
+// BuiltinBudgetTemplates are available to every user
+var BuiltinBudgetTemplates = []BudgetTemplate{
+	{
+		Key:         "50-30-20",
+		Name:        "50/30/20",
+		Description: "50% needs, 30% wants, 20% left unbudgeted for savings",
+		Builtin:     true,
+		Items: []BudgetTemplateItem{
+			{Name: "Housing", Share: 0.25, Icon: "house", Color: "blue"},
+			{Name: "Groceries", Share: 0.10, Icon: "shopping-cart", Color: "orange"},
+			{Name: "Transport", Share: 0.08, Icon: "car", Color: "slate"},
+			{Name: "Utilities", Share: 0.07, Icon: "zap", Color: "yellow"},
+			{Name: "Food & Drink", Share: 0.10, Icon: "coffee", Color: "amber"},
+			{Name: "Entertainment", Share: 0.10, Icon: "music", Color: "purple"},
+			{Name: "Shopping", Share: 0.10, Icon: "shopping-bag", Color: "pink"},
+		},
+	},
+}
+
+// SuggestedBudget is a category limit derived from past spending
+type SuggestedBudget struct {
+	Name           string  `json:"name"`
+	AverageMonthly float64 `json:"averageMonthly"`
+	Limit          float64 `json:"limit"`
+}
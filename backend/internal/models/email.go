@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Email lists a user can subscribe to or unsubscribe from
+const (
+	EmailListWeeklyDigest      = "weekly_digest"
+	EmailListBudgetAlerts      = "budget_alerts"
+	EmailListMonthlyStatements = "monthly_statements"
+)
+
+// EmailPreferences records which email lists a user receives
+type EmailPreferences struct {
+	UserID            primitive.ObjectID `bson:"user_id" json:"user_id"`
+	WeeklyDigest      bool               `bson:"weekly_digest" json:"weekly_digest"`
+	BudgetAlerts      bool               `bson:"budget_alerts" json:"budget_alerts"`
+	MonthlyStatements bool               `bson:"monthly_statements" json:"monthly_statements"`
+	UpdatedAt         time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// DefaultEmailPreferences is used for users who never saved their preferences
+func DefaultEmailPreferences(userID primitive.ObjectID) EmailPreferences {
+	return EmailPreferences{
+		UserID:            userID,
+		WeeklyDigest:      true,
+		BudgetAlerts:      true,
+		MonthlyStatements: true,
+	}
+}
+
+// Subscribed reports whether the user receives the given list
+func (p EmailPreferences) Subscribed(list string) bool {
+	switch list {
+	case EmailListWeeklyDigest:
+		return p.WeeklyDigest
+	case EmailListBudgetAlerts:
+		return p.BudgetAlerts
+	case EmailListMonthlyStatements:
+		return p.MonthlyStatements
+	}
+	return false
+}
+
+// WeeklyDigest summarizes a user's week for the digest email
+type WeeklyDigest struct {
+	PeriodStart      time.Time                `json:"period_start"`
+	PeriodEnd        time.Time                `json:"period_end"`
+	Income           float64                  `json:"income"`
+	Expenses         float64                  `json:"expenses"` // Positive amount
+	Net              float64                  `json:"net"`
+	PreviousExpenses float64                  `json:"previous_expenses"`
+	ExpenseChange    float64                  `json:"expense_change"` // % change from the week before
+	TopCategories    []StatementCategory      `json:"top_categories"`
+	BudgetsAtRisk    []BudgetCategoryOverview `json:"budgets_at_risk"` // Warning or critical this month
+	OpenAnomalies    int64                    `json:"open_anomalies"`
+}
@@ -14,5 +14,108 @@ type Goal struct {
 	CurrentAmount float64            `bson:"current_amount" json:"current_amount"`
 	Color         string             `bson:"color" json:"color"` // e.g., "bg-blue-500"
 	Icon          string             `bson:"icon" json:"icon"`   // e.g., "house"
+	TargetDate    *time.Time         `bson:"target_date,omitempty" json:"target_date,omitempty"`
+	Priority      int                `bson:"priority" json:"priority"`                     // 1 is highest, 0 means unset
+	Accounts      []GoalAccountLink  `bson:"accounts,omitempty" json:"accounts,omitempty"` // When set, CurrentAmount is derived from these accounts
+	Status        string             `bson:"status,omitempty" json:"status"`               // "active", "paused", "achieved" or "archived"
+	Milestones    []int              `bson:"milestones_reached,omitempty" json:"milestones_reached"`
+	AchievedAt    *time.Time         `bson:"achieved_at,omitempty" json:"achieved_at,omitempty"`
+	ArchivedAt    *time.Time         `bson:"archived_at,omitempty" json:"archived_at,omitempty"`
 	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
 }
+
+const (
+	GoalStatusActive   = "active"
+	GoalStatusPaused   = "paused"
+	GoalStatusAchieved = "achieved"
+	GoalStatusArchived = "archived"
+)
+
+// GoalMilestones are the progress percentages that emit a milestone event
+var GoalMilestones = []int{25, 50, 75, 100}
+
+// State returns the lifecycle status, treating goals saved before statuses existed as active
+func (g Goal) State() string {
+	if g.Status == "" {
+		return GoalStatusActive
+	}
+	return g.Status
+}
+
+const (
+	GoalProgressOnTrack    = "on_track"
+	GoalProgressBehind     = "behind"
+	GoalProgressAchieved   = "achieved"
+	GoalProgressNoDeadline = "no_deadline"
+)
+
+// GoalProgress is computed by GetGoals from the goal and its recent contributions
+type GoalProgress struct {
+	Percentage          float64    `json:"percentage"`
+	Remaining           float64    `json:"remaining"`
+	MonthlyPace         float64    `json:"monthly_pace"`         // Average net contribution per month recently
+	RequiredMonthly     float64    `json:"required_monthly"`     // Needed per month to hit the target date
+	ProjectedCompletion *time.Time `json:"projected_completion"` // nil when the pace is not positive
+	Status              string     `json:"status"`               // "on_track", "behind", "achieved" or "no_deadline"
+}
+
+// GoalWithProgress is a goal as returned by GetGoals
+type GoalWithProgress struct {
+	Goal     `bson:",inline"`
+	Progress GoalProgress `json:"progress"`
+}
+
+const (
+	ContributionDeposit    = "deposit"
+	ContributionWithdraw   = "withdraw"
+	ContributionAdjustment = "adjustment" // Balance correction via the legacy current_amount update
+	ContributionOpening    = "opening"    // Balance the goal was created with
+)
+
+// GoalContribution is a ledger entry that moved money into or out of a goal
+type GoalContribution struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	GoalID    primitive.ObjectID `bson:"goal_id" json:"goal_id"`
+	Type      string             `bson:"type" json:"type"`     // "deposit", "withdraw", "adjustment" or "opening"
+	Amount    float64            `bson:"amount" json:"amount"` // Signed effect on the goal balance
+	Date      time.Time          `bson:"date" json:"date"`
+	Note      string             `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+
+	// Set when the contribution was created by a funding rule
+	RuleID        *primitive.ObjectID `bson:"rule_id,omitempty" json:"rule_id,omitempty"`
+	TransactionID *primitive.ObjectID `bson:"transaction_id,omitempty" json:"transaction_id,omitempty"`
+}
+
+const (
+	FundingTriggerIncome   = "income"
+	FundingTriggerSchedule = "schedule"
+)
+
+// GoalFundingRule moves money into goals automatically, either as a share or fixed
+// amount of matching income transactions, or as a fixed amount on a schedule.
+// Without a GoalID the amount is allocated across goals as a waterfall by priority.
+type GoalFundingRule struct {
+	ID      primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID  primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Name    string              `bson:"name" json:"name"`
+	Trigger string              `bson:"trigger" json:"trigger"` // "income" or "schedule"
+	GoalID  *primitive.ObjectID `bson:"goal_id,omitempty" json:"goal_id,omitempty"`
+
+	Percentage float64 `bson:"percentage" json:"percentage"` // Share of the income amount (0-100), income trigger only
+	Amount     float64 `bson:"amount" json:"amount"`         // Fixed amount per match or per scheduled run
+
+	// Income trigger: transaction filters, empty matches any income
+	MatchCategory    string `bson:"match_category,omitempty" json:"match_category,omitempty"`
+	MatchDescription string `bson:"match_description,omitempty" json:"match_description,omitempty"` // Case-insensitive substring
+
+	// Schedule trigger
+	Frequency string    `bson:"frequency,omitempty" json:"frequency,omitempty"`
+	Interval  int       `bson:"interval,omitempty" json:"interval,omitempty"`
+	NextDate  time.Time `bson:"next_date,omitempty" json:"next_date,omitempty"`
+
+	Active    bool      `bson:"active" json:"active"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Identity links a user to an account at an external login provider. A user can
+// have several, one per provider, alongside an optional password.
+type Identity struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Provider string             `bson:"provider" json:"provider"` // "google" or "apple"
+	Subject  string             `bson:"subject" json:"-"`         // The provider's stable user ID
+	Email    string             `bson:"email,omitempty" json:"email,omitempty"`
+	// PrivateEmail marks an Apple Hide My Email relay address
+	PrivateEmail bool      `bson:"private_email,omitempty" json:"private_email,omitempty"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+}
+
+// LinkRequest is a provider login whose email matched an existing account. The
+// identity is only linked once the owner of that account confirms it.
+type LinkRequest struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Provider string             `bson:"provider" json:"provider"`
+	Subject  string             `bson:"subject" json:"-"`
+	Email    string             `bson:"email" json:"email"`
+	// PrivateEmail marks an Apple Hide My Email relay address
+	PrivateEmail bool      `bson:"private_email,omitempty" json:"private_email,omitempty"`
+	TokenHash    string    `bson:"token_hash" json:"-"`
+	Attempts     int       `bson:"attempts" json:"attempts"`
+	ExpiresAt    time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Asset kinds
+const (
+	AssetKindAsset     = "asset"
+	AssetKindLiability = "liability"
+)
+
+// Asset is a manually valued item outside the tracked accounts, e.g. a house, a car
+// or a mortgage. Value is always positive; Kind decides whether it adds to or
+// subtracts from net worth.
+type Asset struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name      string             `bson:"name" json:"name"`
+	Kind      string             `bson:"kind" json:"kind"`         // "asset" or "liability"
+	Category  string             `bson:"category" json:"category"` // Free-form, e.g. "real_estate", "vehicle"
+	Value     float64            `bson:"value" json:"value"`
+	ValuedAt  time.Time          `bson:"valued_at" json:"valued_at"`
+	Note      string             `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Net worth item sources
+const (
+	NetWorthSourceAccount = "account"
+	NetWorthSourceGoal    = "goal"
+	NetWorthSourceAsset   = "asset"
+)
+
+// NetWorthItem is one component of net worth. Value is negative for liabilities.
+type NetWorthItem struct {
+	Source string             `json:"source"` // "account", "goal" or "asset"
+	ID     primitive.ObjectID `json:"id"`
+	Name   string             `json:"name"`
+	Type   string             `json:"type"` // Account type, "goal" or the asset category
+	Value  float64            `json:"value"`
+}
+
+// NetWorth is the user's current net worth and its composition
+type NetWorth struct {
+	Assets      float64            `json:"assets"`
+	Liabilities float64            `json:"liabilities"` // Positive amount
+	NetWorth    float64            `json:"net_worth"`
+	BySource    map[string]float64 `json:"by_source"`
+	Items       []NetWorthItem     `json:"items"`
+}
+
+// NetWorthSnapshot is the net worth of a user at the end of a day
+type NetWorthSnapshot struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Date        string             `bson:"date" json:"date"` // "2006-01-02" in the user's time zone
+	Assets      float64            `bson:"assets" json:"assets"`
+	Liabilities float64            `bson:"liabilities" json:"liabilities"`
+	NetWorth    float64            `bson:"net_worth" json:"net_worth"`
+	BySource    map[string]float64 `bson:"by_source" json:"by_source"`
+	TakenAt     time.Time          `bson:"taken_at" json:"taken_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification is an entry in a user's in-app inbox
+type Notification struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID     `bson:"user_id" json:"user_id"`
+	Type      string                 `bson:"type" json:"type"` // e.g. "budget_alert"
+	Title     string                 `bson:"title" json:"title"`
+	Message   string                 `bson:"message" json:"message"`
+	Data      map[string]interface{} `bson:"data,omitempty" json:"data,omitempty"`
+	Read      bool                   `bson:"read" json:"read"`
+	CreatedAt time.Time              `bson:"created_at" json:"created_at"`
+}
+
+// NotificationSettings controls which channels a user's alerts are delivered through
+type NotificationSettings struct {
+	UserID        primitive.ObjectID `bson:"user_id" json:"user_id"`
+	InApp         bool               `bson:"in_app" json:"in_app"`
+	Email         bool               `bson:"email" json:"email"`
+	WebhookURL    string             `bson:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+	WebhookSecret string             `bson:"webhook_secret,omitempty" json:"webhook_secret,omitempty"` // Used to sign webhook payloads
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// DefaultNotificationSettings is used for users who never saved their preferences
+func DefaultNotificationSettings(userID primitive.ObjectID) NotificationSettings {
+	return NotificationSettings{
+		UserID: userID,
+		InApp:  true,
+		Email:  false,
+	}
+}
+
+// BudgetAlert records that a category crossed a threshold in a given period.
+// (user_id, budget_id, threshold, period) is unique so each alert fires once.
+type BudgetAlert struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	BudgetID   primitive.ObjectID `bson:"budget_id" json:"budget_id"`
+	Category   string             `bson:"category" json:"category"`
+	Threshold  float64            `bson:"threshold" json:"threshold"` // Percentage of the limit, e.g. 80
+	Period     string             `bson:"period" json:"period"`       // "2006-01"
+	Spent      float64            `bson:"spent" json:"spent"`
+	Limit      float64            `bson:"limit" json:"limit"`
+	Percentage float64            `bson:"percentage" json:"percentage"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
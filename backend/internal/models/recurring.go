@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecurringSchedule describes a transaction expected to repeat (rent, salary, subscriptions)
+type RecurringSchedule struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Description string             `bson:"description" json:"description"`
+	Category    string             `bson:"category" json:"category"`
+	Amount      float64            `bson:"amount" json:"amount"`       // Same sign convention as Transaction.Amount
+	Type        string             `bson:"type" json:"type"`           // "income" or "expense"
+	Frequency   string             `bson:"frequency" json:"frequency"` // "weekly", "monthly" or "yearly"
+	Interval    int                `bson:"interval" json:"interval"`   // Every N frequency units, defaults to 1
+	NextDate    time.Time          `bson:"next_date" json:"next_date"`
+	Active      bool               `bson:"active" json:"active"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Advance returns the occurrence following t
+func (r RecurringSchedule) Advance(t time.Time) time.Time {
+	return AdvanceDate(t, r.Frequency, r.Interval)
+}
+
+// AdvanceDate steps t forward by interval units of frequency ("weekly", "monthly" or "yearly")
+func AdvanceDate(t time.Time, frequency string, interval int) time.Time {
+	if interval <= 0 {
+		interval = 1
+	}
+	switch frequency {
+	case "weekly":
+		return t.AddDate(0, 0, 7*interval)
+	case "yearly":
+		return t.AddDate(interval, 0, 0)
+	default:
+		return t.AddDate(0, interval, 0)
+	}
+}
+
+// OccurrencesBetween returns the expected dates of the schedule within [from, to)
+func (r RecurringSchedule) OccurrencesBetween(from, to time.Time) []time.Time {
+	var dates []time.Time
+	if r.NextDate.IsZero() {
+		return dates
+	}
+	for t := r.NextDate; t.Before(to); t = r.Advance(t) {
+		if !t.Before(from) {
+			dates = append(dates, t)
+		}
+	}
+	return dates
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportPeriod is a [From, To) date range
+type ReportPeriod struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// ReportPeriods are the reported period and its comparison columns
+type ReportPeriods struct {
+	Current  ReportPeriod `json:"current"`
+	Previous ReportPeriod `json:"previous"`  // Same length, immediately before Current
+	LastYear ReportPeriod `json:"last_year"` // Current shifted back one year
+}
+
+// ReportAmounts holds one figure for the reported period and each comparison column
+type ReportAmounts struct {
+	Current  float64 `json:"current"`
+	Previous float64 `json:"previous"`
+	LastYear float64 `json:"last_year"`
+}
+
+// ReportLine is a named row of a report
+type ReportLine struct {
+	Name string `json:"name"`
+	ReportAmounts
+}
+
+// ReportSection groups lines with their subtotal
+type ReportSection struct {
+	Lines    []ReportLine  `json:"lines"`
+	Subtotal ReportAmounts `json:"subtotal"`
+}
+
+// IncomeStatement is income and expense by category for a period
+type IncomeStatement struct {
+	Periods   ReportPeriods `json:"periods"`
+	Timezone  string        `json:"timezone"`
+	Income    ReportSection `json:"income"`
+	Expenses  ReportSection `json:"expenses"` // Shown as positive amounts
+	NetIncome ReportAmounts `json:"net_income"`
+}
+
+// AccountCashFlow is the money movement of one account in a period. AccountID is nil
+// for transactions not booked against any account.
+type AccountCashFlow struct {
+	AccountID      *primitive.ObjectID `json:"account_id"`
+	Name           string              `json:"name"`
+	OpeningBalance float64             `json:"opening_balance"`
+	Inflows        ReportAmounts       `json:"inflows"`
+	Outflows       ReportAmounts       `json:"outflows"` // Shown as positive amounts
+	TransfersIn    ReportAmounts       `json:"transfers_in"`
+	TransfersOut   ReportAmounts       `json:"transfers_out"` // Shown as positive amounts
+	NetChange      ReportAmounts       `json:"net_change"`
+	ClosingBalance float64             `json:"closing_balance"`
+}
+
+// CashFlowReport lists the cash flow of every account plus a total row
+type CashFlowReport struct {
+	Periods  ReportPeriods     `json:"periods"`
+	Timezone string            `json:"timezone"`
+	Accounts []AccountCashFlow `json:"accounts"`
+	Total    AccountCashFlow   `json:"total"`
+}
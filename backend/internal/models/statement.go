@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MonthlyStatement is the data rendered into a monthly financial statement
+type MonthlyStatement struct {
+	UserName            string                   `json:"user_name"`
+	Period              string                   `json:"period"` // "2006-01"
+	PeriodStart         time.Time                `json:"period_start"`
+	PeriodEnd           time.Time                `json:"period_end"`
+	Timezone            string                   `json:"timezone"`
+	Income              float64                  `json:"income"`
+	Expenses            float64                  `json:"expenses"` // Positive amount
+	Net                 float64                  `json:"net"`
+	SavingsRate         float64                  `json:"savings_rate"` // % of income
+	Categories          []StatementCategory      `json:"categories"`
+	Budgets             []BudgetCategoryOverview `json:"budgets"`
+	Goals               []GoalWithProgress       `json:"goals"`
+	LargestTransactions []Transaction            `json:"largest_transactions"`
+	GeneratedAt         time.Time                `json:"generated_at"`
+}
+
+// StatementCategory is one row of the statement's spending breakdown
+type StatementCategory struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+	Share  float64 `json:"share"` // % of total expenses
+}
+
+// StoredStatement is a rendered statement filed by the monthly scheduler
+type StoredStatement struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Period      string             `bson:"period" json:"period"`
+	HTML        []byte             `bson:"html" json:"-"`
+	PDF         []byte             `bson:"pdf" json:"-"`
+	GeneratedAt time.Time          `bson:"generated_at" json:"generated_at"`
+}
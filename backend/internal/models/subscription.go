@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Subscription review states
+const (
+	SubscriptionDetected  = "detected"
+	SubscriptionConfirmed = "confirmed" // Turned into a RecurringSchedule
+	SubscriptionDismissed = "dismissed" // Not a subscription, hidden from the list
+)
+
+// Subscription is a periodic charge detected in the transaction history
+type Subscription struct {
+	ID          primitive.ObjectID       `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID       `bson:"user_id" json:"user_id"`
+	Merchant    string                   `bson:"merchant" json:"merchant"` // Normalized payee used for matching
+	Name        string                   `bson:"name" json:"name"`         // Description of the latest charge
+	Category    string                   `bson:"category" json:"category"`
+	Amount      float64                  `bson:"amount" json:"amount"` // Latest charge, positive
+	Frequency   string                   `bson:"frequency" json:"frequency"`
+	Interval    int                      `bson:"interval" json:"interval"`
+	Occurrences int                      `bson:"occurrences" json:"occurrences"`
+	LastDate    time.Time                `bson:"last_date" json:"last_date"`
+	NextDate    time.Time                `bson:"next_date" json:"next_date"` // Next expected charge
+	AnnualCost  float64                  `bson:"annual_cost" json:"annual_cost"`
+	Active      bool                     `bson:"active" json:"active"` // False once an expected charge is well overdue
+	PriceChange *SubscriptionPriceChange `bson:"price_change,omitempty" json:"price_change,omitempty"`
+	Status      string                   `bson:"status" json:"status"` // "detected", "confirmed" or "dismissed"
+	ScheduleID  *primitive.ObjectID      `bson:"schedule_id,omitempty" json:"schedule_id,omitempty"`
+	DetectedAt  time.Time                `bson:"detected_at" json:"detected_at"`
+	UpdatedAt   time.Time                `bson:"updated_at" json:"updated_at"`
+}
+
+// SubscriptionPriceChange is the latest change in the charged amount
+type SubscriptionPriceChange struct {
+	PreviousAmount float64   `bson:"previous_amount" json:"previous_amount"`
+	NewAmount      float64   `bson:"new_amount" json:"new_amount"`
+	Percent        float64   `bson:"percent" json:"percent"`
+	Date           time.Time `bson:"date" json:"date"` // First charge at the new amount
+}
@@ -7,11 +7,13 @@ import (
 )
 
 type User struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name      string             `bson:"name" json:"name" validate:"required"`
-	Email     string             `bson:"email" json:"email" validate:"required,email"`
-	Password  string             `bson:"password" json:"-"`
-	Provider  string             `bson:"provider,omitempty" json:"provider,omitempty"` // "google", "apple", or empty for email/pass
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name          string             `bson:"name" json:"name" validate:"required"`
+	Email         string             `bson:"email" json:"email" validate:"required,email"`
+	EmailVerified bool               `bson:"email_verified" json:"email_verified"`
+	Password      string             `bson:"password" json:"-"`
+	Provider      string             `bson:"provider,omitempty" json:"provider,omitempty"` // "google", "apple", or empty for email/pass
+	Timezone      string             `bson:"timezone,omitempty" json:"timezone,omitempty"` // IANA name, e.g. "Europe/Berlin"; empty means UTC
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
 }
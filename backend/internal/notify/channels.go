@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/email"
+	"fintrack-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InboxChannel stores messages in the notifications collection for the in-app inbox
+type InboxChannel struct{}
+
+func (InboxChannel) Name() string { return "in_app" }
+
+func (InboxChannel) Enabled(r Recipient) bool { return r.Settings.InApp }
+
+func (InboxChannel) Send(ctx context.Context, r Recipient, msg Message) error {
+	notification := models.Notification{
+		ID:        primitive.NewObjectID(),
+		UserID:    msg.UserID,
+		Type:      msg.Type,
+		Title:     msg.Title,
+		Message:   msg.Body,
+		Data:      msg.Data,
+		CreatedAt: time.Now(),
+	}
+	_, err := db.Client.Database("fintrack").Collection("notifications").InsertOne(ctx, notification)
+	return err
+}
+
+// EmailChannel sends messages as templated emails. Message types with their own
+// template use it, everything else the generic "notification" template.
+type EmailChannel struct{}
+
+// emailLists maps message types to the email list users can unsubscribe from
+var emailLists = map[string]string{
+	"budget_alert": models.EmailListBudgetAlerts,
+}
+
+// emailSkipped lists message types that are mailed separately with richer content
+var emailSkipped = map[string]bool{
+	"statement_ready": true, // Sent with the PDF attached by the statement job
+}
+
+func (EmailChannel) Name() string { return "email" }
+
+func (EmailChannel) Enabled(r Recipient) bool { return r.Settings.Email && r.User.Email != "" }
+
+func (EmailChannel) Send(ctx context.Context, r Recipient, msg Message) error {
+	if emailSkipped[msg.Type] {
+		return nil
+	}
+	name := "notification"
+	if email.HasTemplate(msg.Type) {
+		name = msg.Type
+	}
+	return email.Send(ctx, r.User, emailLists[msg.Type], name, msg)
+}
+
+// WebhookChannel POSTs messages as JSON to the user's webhook URL.
+// When a secret is configured the body is signed with HMAC-SHA256 in X-FinTrack-Signature.
+type WebhookChannel struct{}
+
+func (WebhookChannel) Name() string { return "webhook" }
+
+func (WebhookChannel) Enabled(r Recipient) bool { return r.Settings.WebhookURL != "" }
+
+func (WebhookChannel) Send(ctx context.Context, r Recipient, msg Message) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":    msg.Type,
+		"title":   msg.Title,
+		"message": msg.Body,
+		"data":    msg.Data,
+		"sent_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// URLs saved before https was required are not delivered to
+	if err := ValidateWebhookURL(r.Settings.WebhookURL); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.Settings.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.Settings.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(r.Settings.WebhookSecret))
+		mac.Write(payload)
+		req.Header.Set("X-FinTrack-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
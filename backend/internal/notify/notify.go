@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"fintrack-backend/internal/db"
+	"fintrack-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Message is a channel-agnostic notification addressed to one user
+type Message struct {
+	UserID primitive.ObjectID
+	Type   string // e.g. "budget_alert"
+	Title  string
+	Body   string
+	Data   map[string]interface{}
+}
+
+// Recipient bundles what channels need to know about the user being notified
+type Recipient struct {
+	User     models.User
+	Settings models.NotificationSettings
+}
+
+// Channel delivers a message through one medium (inbox, email, webhook, ...)
+type Channel interface {
+	Name() string
+	// Enabled reports whether the recipient wants messages on this channel
+	Enabled(r Recipient) bool
+	Send(ctx context.Context, r Recipient, msg Message) error
+}
+
+var channels []Channel
+
+// Register adds a delivery channel used by Dispatch
+func Register(ch Channel) {
+	channels = append(channels, ch)
+}
+
+func init() {
+	Register(InboxChannel{})
+	Register(EmailChannel{})
+	Register(WebhookChannel{})
+}
+
+// LoadSettings returns the user's notification settings, or the defaults if none are saved
+func LoadSettings(ctx context.Context, userID primitive.ObjectID) models.NotificationSettings {
+	var settings models.NotificationSettings
+	err := db.Client.Database("fintrack").Collection("notification_settings").FindOne(ctx, bson.M{"user_id": userID}).Decode(&settings)
+	if err != nil {
+		return models.DefaultNotificationSettings(userID)
+	}
+	return settings
+}
+
+// Dispatch delivers msg through every channel the user has enabled.
+// Channel failures are logged and do not stop delivery on other channels.
+func Dispatch(ctx context.Context, msg Message) {
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": msg.UserID}).Decode(&user); err != nil {
+		log.Printf("[notify] user %s not found: %v", msg.UserID.Hex(), err)
+		return
+	}
+
+	recipient := Recipient{User: user, Settings: LoadSettings(ctx, msg.UserID)}
+
+	for _, ch := range channels {
+		if !ch.Enabled(recipient) {
+			continue
+		}
+		if err := ch.Send(ctx, recipient, msg); err != nil {
+			log.Printf("[notify] %s delivery failed for user %s: %v", ch.Name(), msg.UserID.Hex(), err)
+		}
+	}
+}
+
+// DispatchAsync runs Dispatch in the background with its own timeout
+func DispatchAsync(msg Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		Dispatch(ctx, msg)
+	}()
+}
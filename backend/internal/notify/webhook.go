@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	errWebhookURL     = errors.New("webhook URL must be an https URL with a public host name")
+	errWebhookAddress = errors.New("webhook host resolves to a non-public address")
+)
+
+// Carrier-grade NAT space, not covered by netip.Addr.IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// webhookClient only connects to public addresses. The check runs on the resolved
+// address of every connection, redirects included, so a host name that later
+// resolves to an internal address (DNS rebinding) is refused as well.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil, // A proxy would be dialed instead of the webhook host
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				addr, err := netip.ParseAddr(host)
+				if err != nil || !publicAddr(addr) {
+					return errWebhookAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// publicAddr reports whether addr is routable on the internet
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// ValidateWebhookURL checks a user-supplied webhook URL before it is saved. Only
+// https URLs with a host name are accepted; IP literals and localhost are refused
+// outright, other hosts are checked again when they are dialed.
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return errWebhookURL
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errWebhookURL
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return errWebhookURL
+	}
+	return nil
+}
@@ -19,15 +19,45 @@ func SetupRoutes(r *gin.Engine) {
 		{
 			auth.POST("/register", handlers.Register)
 			auth.POST("/login", handlers.Login)
+			auth.POST("/refresh", handlers.RefreshSession)
+			auth.POST("/logout", handlers.Logout)
+			auth.POST("/forgot-password", handlers.ForgotPassword)
+			auth.POST("/reset-password", handlers.ResetPassword)
+			auth.POST("/verify-email", handlers.VerifyEmail)
+			auth.POST("/2fa/verify", handlers.VerifyTwoFactorLogin)
 			auth.GET("/google", handlers.GoogleLogin)
 			auth.GET("/google/callback", handlers.GoogleCallback)
+			auth.POST("/exchange", handlers.ExchangeOAuthCode)
+			auth.POST("/link/confirm", handlers.ConfirmAccountLink)
 			auth.GET("/apple", handlers.AppleLogin)
+			auth.POST("/apple/callback", handlers.AppleCallback)
 		}
 
+		// Unsubscribe links in emails work without logging in
+		api.GET("/email/unsubscribe", handlers.UnsubscribeConfirm)
+		api.POST("/email/unsubscribe", handlers.Unsubscribe)
+
 		// Protected Routes
 		protected := r.Group("/api")
 		protected.Use(middleware.AuthMiddleware())
 		{
+			protected.POST("/auth/logout-all", handlers.LogoutAll)
+			protected.POST("/auth/resend-verification", handlers.ResendVerificationEmail)
+			protected.GET("/auth/2fa", handlers.GetTwoFactorStatus)
+			protected.POST("/auth/2fa/setup", handlers.SetupTwoFactor)
+			protected.POST("/auth/2fa/enable", handlers.EnableTwoFactor)
+			protected.POST("/auth/2fa/disable", handlers.DisableTwoFactor)
+			protected.POST("/auth/2fa/recovery-codes", handlers.RegenerateRecoveryCodes)
+			protected.GET("/auth/identities", handlers.GetIdentities)
+			protected.POST("/auth/identities/:provider/link", handlers.LinkProvider)
+			protected.DELETE("/auth/identities/:provider", handlers.UnlinkProvider)
+			protected.GET("/sessions", handlers.GetSessions)
+			protected.DELETE("/sessions/:id", handlers.RevokeSession)
+
+			// Profile
+			protected.GET("/profile", handlers.GetProfile)
+			protected.PUT("/profile", handlers.UpdateProfile)
+
 			// Dashboard & Transactions
 			protected.GET("/dashboard", handlers.GetDashboardData)
 			protected.GET("/transactions", handlers.GetTransactions)
@@ -39,12 +69,82 @@ func SetupRoutes(r *gin.Engine) {
 			protected.POST("/budget/category", handlers.CreateBudgetCategory)
 			protected.PUT("/budget/category/:id", handlers.UpdateBudgetCategory)
 			protected.DELETE("/budget/category/:id", handlers.DeleteBudgetCategory)
+			protected.GET("/budget/alerts", handlers.GetBudgetAlerts)
+			protected.POST("/budget/categories/bulk", handlers.BulkUpsertBudgetCategories)
+			protected.GET("/budget/suggest", handlers.SuggestBudget)
+			protected.GET("/budget/templates", handlers.GetBudgetTemplates)
+			protected.POST("/budget/templates", handlers.CreateBudgetTemplate)
+			protected.DELETE("/budget/templates/:id", handlers.DeleteBudgetTemplate)
+			protected.POST("/budget/templates/:id/apply", handlers.ApplyBudgetTemplate)
+
+			// Notifications
+			protected.GET("/notifications", handlers.GetNotifications)
+			protected.PUT("/notifications/:id/read", handlers.MarkNotificationRead)
+			protected.GET("/notifications/settings", handlers.GetNotificationSettings)
+			protected.PUT("/notifications/settings", handlers.UpdateNotificationSettings)
+			protected.GET("/email/preferences", handlers.GetEmailPreferences)
+			protected.PUT("/email/preferences", handlers.UpdateEmailPreferences)
+
+			// Recurring schedules
+			protected.GET("/recurring", handlers.GetRecurringSchedules)
+			protected.POST("/recurring", handlers.CreateRecurringSchedule)
+			protected.PUT("/recurring/:id", handlers.UpdateRecurringSchedule)
+			protected.DELETE("/recurring/:id", handlers.DeleteRecurringSchedule)
+
+			// Detected subscriptions
+			protected.GET("/subscriptions", handlers.GetSubscriptions)
+			protected.POST("/subscriptions/:id/confirm", handlers.ConfirmSubscription)
+			protected.POST("/subscriptions/:id/dismiss", handlers.DismissSubscription)
+
+			// Spending anomalies
+			protected.GET("/anomalies", handlers.GetAnomalies)
+			protected.PUT("/anomalies/:id/status", handlers.UpdateAnomalyStatus)
 
 			// Goals
 			protected.GET("/goals", handlers.GetGoals)
 			protected.POST("/goals", handlers.CreateGoal)
 			protected.PUT("/goals/:id", handlers.UpdateGoal)
 			protected.DELETE("/goals/:id", handlers.DeleteGoal)
+			protected.PUT("/goals/:id/status", handlers.UpdateGoalStatus)
+			protected.GET("/goals/:id/contributions", handlers.GetGoalContributions)
+			protected.POST("/goals/:id/contributions", handlers.CreateGoalContribution)
+			protected.DELETE("/goals/:id/contributions/:contributionId", handlers.DeleteGoalContribution)
+
+			protected.PUT("/goals/:id/accounts", handlers.SetGoalAccounts)
+
+			// Accounts
+			protected.GET("/accounts", handlers.GetAccounts)
+			protected.POST("/accounts", handlers.CreateAccount)
+			protected.PUT("/accounts/:id", handlers.UpdateAccount)
+			protected.DELETE("/accounts/:id", handlers.DeleteAccount)
+			protected.POST("/accounts/transfer", handlers.CreateTransfer)
+
+			// Net worth
+			protected.GET("/net-worth", handlers.GetNetWorth)
+			protected.GET("/assets", handlers.GetAssets)
+			protected.POST("/assets", handlers.CreateAsset)
+			protected.PUT("/assets/:id", handlers.UpdateAsset)
+			protected.DELETE("/assets/:id", handlers.DeleteAsset)
+
+			// Reports
+			protected.GET("/reports/income-statement", handlers.GetIncomeStatement)
+			protected.GET("/reports/cash-flow", handlers.GetCashFlowReport)
+			protected.GET("/statements", handlers.GetStatements)
+			protected.GET("/statements/:period", middleware.RequireVerifiedEmail(), handlers.DownloadStatement)
+
+			// Goal funding rules
+			protected.GET("/funding-rules", handlers.GetFundingRules)
+			protected.POST("/funding-rules", handlers.CreateFundingRule)
+			protected.PUT("/funding-rules/:id", handlers.UpdateFundingRule)
+			protected.DELETE("/funding-rules/:id", handlers.DeleteFundingRule)
+			protected.GET("/funding-rules/waterfall", handlers.PreviewWaterfall)
+		}
+
+		// v2: presentation-neutral responses for non-web clients
+		v2 := r.Group("/api/v2")
+		v2.Use(middleware.AuthMiddleware())
+		{
+			v2.GET("/budget", handlers.GetBudgetOverviewV2)
 		}
 
 		api.GET("/seed", handlers.SeedData) // Keep seed public for now or protect it too
@@ -0,0 +1,272 @@
+// Package statement renders monthly financial statements as HTML and PDF
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+
+	"fintrack-backend/internal/models"
+)
+
+// Rows shown per section of a statement
+const maxRows = 12
+
+func money(v float64) string {
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	whole := int64(v)
+	cents := int64(math.Round((v - float64(whole)) * 100))
+	if cents == 100 {
+		whole++
+		cents = 0
+	}
+
+	digits := fmt.Sprintf("%d", whole)
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(d)
+	}
+	return fmt.Sprintf("%s$%s.%02d", sign, grouped.String(), cents)
+}
+
+// Money formats an amount the way statements show it, e.g. "-$1,234.50"
+func Money(v float64) string {
+	return money(v)
+}
+
+func percent(v float64) string {
+	return fmt.Sprintf("%.0f%%", v)
+}
+
+func statusLabel(status string) string {
+	return strings.ReplaceAll(status, "_", " ")
+}
+
+func limit[T any](rows []T) []T {
+	if len(rows) > maxRows {
+		return rows[:maxRows]
+	}
+	return rows
+}
+
+var htmlTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"money":   money,
+	"percent": percent,
+	"status":  statusLabel,
+	"abs":     math.Abs,
+	"date":    func(t time.Time) string { return t.Format("Jan 2, 2006") },
+	"month":   func(t time.Time) string { return t.Format("January 2006") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>FinTrack statement {{month .PeriodStart}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #0f172a; max-width: 760px; margin: 32px auto; }
+h1 { margin-bottom: 0; }
+h2 { margin-top: 32px; border-bottom: 1px solid #e2e8f0; padding-bottom: 4px; font-size: 16px; }
+.muted { color: #64748b; }
+.summary { display: flex; gap: 16px; margin-top: 24px; }
+.summary div { flex: 1; background: #f8fafc; border-radius: 8px; padding: 12px; }
+.summary strong { display: block; font-size: 20px; }
+table { width: 100%; border-collapse: collapse; font-size: 14px; }
+th, td { text-align: left; padding: 6px 4px; border-bottom: 1px solid #f1f5f9; }
+td.num, th.num { text-align: right; }
+.on_track { color: #16a34a; } .warning { color: #ea580c; } .critical { color: #dc2626; }
+</style>
+</head>
+<body>
+<h1>Monthly statement</h1>
+<p class="muted">{{month .PeriodStart}} &middot; {{.UserName}} &middot; generated {{date .GeneratedAt}}</p>
+
+<div class="summary">
+  <div>Income<strong>{{money .Income}}</strong></div>
+  <div>Expenses<strong>{{money .Expenses}}</strong></div>
+  <div>Net<strong>{{money .Net}}</strong></div>
+  <div>Savings rate<strong>{{percent .SavingsRate}}</strong></div>
+</div>
+
+<h2>Spending by category</h2>
+{{if .Categories}}<table>
+<tr><th>Category</th><th class="num">Amount</th><th class="num">Share</th></tr>
+{{range .Categories}}<tr><td>{{.Name}}</td><td class="num">{{money .Amount}}</td><td class="num">{{percent .Share}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No expenses this month.</p>{{end}}
+
+<h2>Budget performance</h2>
+{{if .Budgets}}<table>
+<tr><th>Budget</th><th class="num">Limit</th><th class="num">Spent</th><th class="num">Used</th><th>Status</th></tr>
+{{range .Budgets}}<tr><td>{{.Name}}</td><td class="num">{{money .Limit}}</td><td class="num">{{money .Spent}}</td><td class="num">{{percent .Percentage}}</td><td class="{{.Status}}">{{status .Status}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No budgets set up.</p>{{end}}
+
+<h2>Goal progress</h2>
+{{if .Goals}}<table>
+<tr><th>Goal</th><th class="num">Saved</th><th class="num">Target</th><th class="num">Progress</th><th>Status</th></tr>
+{{range .Goals}}<tr><td>{{.Name}}</td><td class="num">{{money .CurrentAmount}}</td><td class="num">{{money .TargetAmount}}</td><td class="num">{{percent .Progress.Percentage}}</td><td>{{status .Progress.Status}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No savings goals.</p>{{end}}
+
+<h2>Largest transactions</h2>
+{{if .LargestTransactions}}<table>
+<tr><th>Date</th><th>Description</th><th>Category</th><th class="num">Amount</th></tr>
+{{range .LargestTransactions}}<tr><td>{{date .Date}}</td><td>{{.Description}}</td><td>{{.Category}}</td><td class="num">{{money (abs .Amount)}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No expenses this month.</p>{{end}}
+</body>
+</html>
+`))
+
+// RenderHTML renders the statement as a standalone HTML page
+func RenderHTML(s models.MonthlyStatement) ([]byte, error) {
+	s.Categories = limit(s.Categories)
+	s.Budgets = limit(s.Budgets)
+	s.Goals = limit(s.Goals)
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfColumn is a table column: its left edge, or right edge when right-aligned
+type pdfColumn struct {
+	Title string
+	X     float64
+	Right bool
+}
+
+// pdfLayout tracks the vertical position while laying out a PDF statement
+type pdfLayout struct {
+	doc *pdfDocument
+	y   float64
+}
+
+const (
+	pdfMargin   = 50.0
+	pdfRowSize  = 16.0
+	pdfFontSize = 9.5
+)
+
+// ensure starts a new page when fewer than height points are left
+func (l *pdfLayout) ensure(height float64) {
+	if l.y+height > pageHeight-pdfMargin {
+		l.doc.addPage()
+		l.y = pdfMargin
+	}
+}
+
+func (l *pdfLayout) heading(title string) {
+	l.ensure(3 * pdfRowSize)
+	l.y += 24
+	l.doc.text(pdfMargin, l.y, 12, true, title)
+	l.y += 6
+	l.doc.line(pdfMargin, l.y, pageWidth-pdfMargin, l.y)
+	l.y += 4
+}
+
+func (l *pdfLayout) row(columns []pdfColumn, values []string, bold bool) {
+	l.ensure(pdfRowSize)
+	l.y += pdfRowSize
+	for i, col := range columns {
+		if col.Right {
+			l.doc.textRight(col.X, l.y, pdfFontSize, bold, values[i])
+		} else {
+			l.doc.text(col.X, l.y, pdfFontSize, bold, values[i])
+		}
+	}
+}
+
+func (l *pdfLayout) table(columns []pdfColumn, rows [][]string, empty string) {
+	if len(rows) == 0 {
+		l.ensure(pdfRowSize)
+		l.y += pdfRowSize
+		l.doc.text(pdfMargin, l.y, pdfFontSize, false, empty)
+		return
+	}
+	titles := make([]string, len(columns))
+	for i, col := range columns {
+		titles[i] = col.Title
+	}
+	l.row(columns, titles, true)
+	for _, r := range rows {
+		l.row(columns, r, false)
+	}
+}
+
+// truncate shortens s to n characters so it fits its column
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// RenderPDF renders the statement as an A4 PDF document
+func RenderPDF(s models.MonthlyStatement) []byte {
+	doc := &pdfDocument{}
+	doc.addPage()
+	l := &pdfLayout{doc: doc, y: pdfMargin + 10}
+
+	doc.text(pdfMargin, l.y, 20, true, "Monthly statement")
+	l.y += 18
+	doc.text(pdfMargin, l.y, 10, false, fmt.Sprintf("%s  •  %s  •  generated %s",
+		s.PeriodStart.Format("January 2006"), s.UserName, s.GeneratedAt.Format("Jan 2, 2006")))
+
+	// Summary boxes
+	l.y += 20
+	boxWidth := (pageWidth - 2*pdfMargin - 3*10) / 4
+	summary := [][2]string{
+		{"Income", money(s.Income)},
+		{"Expenses", money(s.Expenses)},
+		{"Net", money(s.Net)},
+		{"Savings rate", percent(s.SavingsRate)},
+	}
+	for i, item := range summary {
+		x := pdfMargin + float64(i)*(boxWidth+10)
+		doc.rect(x, l.y, boxWidth, 44, 0.97, 0.98, 0.99)
+		doc.text(x+8, l.y+16, 9, false, item[0])
+		doc.text(x+8, l.y+34, 13, true, item[1])
+	}
+	l.y += 44
+
+	right := pageWidth - pdfMargin
+
+	l.heading("Spending by category")
+	var rows [][]string
+	for _, c := range limit(s.Categories) {
+		rows = append(rows, []string{truncate(c.Name, 40), money(c.Amount), percent(c.Share)})
+	}
+	l.table([]pdfColumn{{"Category", pdfMargin, false}, {"Amount", right - 80, true}, {"Share", right, true}}, rows, "No expenses this month.")
+
+	l.heading("Budget performance")
+	rows = nil
+	for _, b := range limit(s.Budgets) {
+		rows = append(rows, []string{truncate(b.Name, 30), money(b.Limit), money(b.Spent), percent(b.Percentage), statusLabel(b.Status)})
+	}
+	l.table([]pdfColumn{{"Budget", pdfMargin, false}, {"Limit", 300, true}, {"Spent", 380, true}, {"Used", 430, true}, {"Status", 450, false}}, rows, "No budgets set up.")
+
+	l.heading("Goal progress")
+	rows = nil
+	for _, g := range limit(s.Goals) {
+		rows = append(rows, []string{truncate(g.Name, 30), money(g.CurrentAmount), money(g.TargetAmount), percent(g.Progress.Percentage), statusLabel(g.Progress.Status)})
+	}
+	l.table([]pdfColumn{{"Goal", pdfMargin, false}, {"Saved", 300, true}, {"Target", 380, true}, {"Progress", 440, true}, {"Status", 450, false}}, rows, "No savings goals.")
+
+	l.heading("Largest transactions")
+	rows = nil
+	for _, t := range s.LargestTransactions {
+		rows = append(rows, []string{t.Date.Format("Jan 2"), truncate(t.Description, 34), truncate(t.Category, 20), money(math.Abs(t.Amount))})
+	}
+	l.table([]pdfColumn{{"Date", pdfMargin, false}, {"Description", 110, false}, {"Category", 330, false}, {"Amount", right, true}}, rows, "No expenses this month.")
+
+	return doc.bytes()
+}